@@ -0,0 +1,88 @@
+package console
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ColorLevel returns the color depth supported by the terminal attached to Stdout. FORCE_COLOR, CLICOLOR and NO_COLOR are respected.
+func ColorLevel() ColorDepth {
+	return colorLevel(os.Stdout)
+}
+
+func colorLevel(f *os.File) ColorDepth {
+	return detectColorLevel(supportsColors() && term.IsTerminal(int(f.Fd())), os.LookupEnv)
+}
+
+// detectColorLevel determines the color depth from the environment.
+func detectColorLevel(isTerminal bool, lookupEnv func(string) (string, bool)) ColorDepth {
+	getenv := func(key string) string {
+		val, _ := lookupEnv(key)
+		return val
+	}
+
+	if level, forced := forcedColorLevel(lookupEnv); forced {
+		return level
+	}
+
+	if len(getenv("NO_COLOR")) > 0 {
+		return ColorDepthNone
+	}
+	if val := getenv("CLICOLOR_FORCE"); len(val) > 0 && val != "0" {
+		return max(ColorDepth16, termColorLevel(getenv))
+	}
+	if getenv("CLICOLOR") == "0" {
+		return ColorDepthNone
+	}
+	if !isTerminal {
+		return ColorDepthNone
+	}
+
+	termName := getenv("TERM")
+	if termName == "" || termName == "dumb" {
+		return ColorDepthNone
+	}
+	return max(ColorDepth16, termColorLevel(getenv))
+}
+
+// forcedColorLevel returns the color depth selected by FORCE_COLOR. forced is false when the variable is not set or unknown.
+func forcedColorLevel(lookupEnv func(string) (string, bool)) (level ColorDepth, forced bool) {
+	val, ok := lookupEnv("FORCE_COLOR")
+	if !ok {
+		return ColorDepthNone, false
+	}
+	switch strings.ToLower(val) {
+	case "", "true":
+		getenv := func(key string) string {
+			val, _ := lookupEnv(key)
+			return val
+		}
+		return max(ColorDepth16, termColorLevel(getenv)), true
+	case "false":
+		return ColorDepthNone, true
+	}
+	if level, err := strconv.Atoi(val); err == nil {
+		return min(max(ColorDepth(level), ColorDepthNone), ColorDepthTrueColor), true
+	}
+	return ColorDepthNone, false
+}
+
+// termColorLevel returns the color depth announced by COLORTERM and TERM.
+func termColorLevel(getenv func(string) string) ColorDepth {
+	switch strings.ToLower(getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return ColorDepthTrueColor
+	}
+
+	termName := getenv("TERM")
+	if strings.HasSuffix(termName, "-direct") || strings.Contains(termName, "truecolor") {
+		return ColorDepthTrueColor
+	}
+	if strings.Contains(termName, "256color") {
+		return ColorDepth256
+	}
+	return ColorDepthNone
+}
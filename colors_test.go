@@ -0,0 +1,47 @@
+package console
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectColorLevel(t *testing.T) {
+	tests := []struct {
+		name       string
+		isTerminal bool
+		env        map[string]string
+		expected   ColorDepth
+	}{
+		{"NoTerminal", false, map[string]string{"TERM": "xterm-256color"}, ColorDepthNone},
+		{"Basic", true, map[string]string{"TERM": "xterm"}, ColorDepth16},
+		{"256Colors", true, map[string]string{"TERM": "xterm-256color"}, ColorDepth256},
+		{"TrueColor", true, map[string]string{"TERM": "xterm-256color", "COLORTERM": "truecolor"}, ColorDepthTrueColor},
+		{"TrueColor24Bit", true, map[string]string{"TERM": "screen", "COLORTERM": "24bit"}, ColorDepthTrueColor},
+		{"Direct", true, map[string]string{"TERM": "xterm-direct"}, ColorDepthTrueColor},
+		{"Dumb", true, map[string]string{"TERM": "dumb", "COLORTERM": "truecolor"}, ColorDepthNone},
+		{"NoTerm", true, map[string]string{}, ColorDepthNone},
+		{"NoColor", true, map[string]string{"TERM": "xterm", "NO_COLOR": "1"}, ColorDepthNone},
+		{"EmptyNoColor", true, map[string]string{"TERM": "xterm", "NO_COLOR": ""}, ColorDepth16},
+		{"CliColorOff", true, map[string]string{"TERM": "xterm", "CLICOLOR": "0"}, ColorDepthNone},
+		{"CliColorOn", true, map[string]string{"TERM": "xterm", "CLICOLOR": "1"}, ColorDepth16},
+		{"CliColorForce", false, map[string]string{"CLICOLOR_FORCE": "1"}, ColorDepth16},
+		{"CliColorForceOff", false, map[string]string{"CLICOLOR_FORCE": "0"}, ColorDepthNone},
+		{"ForceColor", false, map[string]string{"FORCE_COLOR": ""}, ColorDepth16},
+		{"ForceColorTrue", false, map[string]string{"FORCE_COLOR": "true", "TERM": "xterm-256color"}, ColorDepth256},
+		{"ForceColorLevel", false, map[string]string{"FORCE_COLOR": "3"}, ColorDepthTrueColor},
+		{"ForceColorOff", true, map[string]string{"FORCE_COLOR": "0", "TERM": "xterm"}, ColorDepthNone},
+		{"ForceColorFalse", true, map[string]string{"FORCE_COLOR": "false", "TERM": "xterm"}, ColorDepthNone},
+		{"ForceColorOverridesNoColor", false, map[string]string{"FORCE_COLOR": "2", "NO_COLOR": "1"}, ColorDepth256},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lookupEnv := func(key string) (string, bool) {
+				val, ok := test.env[key]
+				return val, ok
+			}
+			assert.Equal(t, test.expected, detectColorLevel(test.isTerminal, lookupEnv))
+		})
+	}
+}
@@ -22,10 +22,18 @@ type CompletionOption interface {
 	IsPartial() bool
 }
 
+// DescribedCompletionOption is implemented by completion options that have a description to display next to the label.
+type DescribedCompletionOption interface {
+	CompletionOption
+	// Description returns a short explanation of the option.
+	Description() string
+}
+
 type completionOption struct {
 	label       string
 	replacement string
 	isPartial   bool
+	description string
 }
 
 // NewCompletionOption returns a new completion option.
@@ -38,6 +46,11 @@ func NewLabelledCompletionOption(label, replacement string, isPartial bool) Comp
 	return &completionOption{label: label, replacement: replacement, isPartial: isPartial}
 }
 
+// NewDescribedCompletionOption returns a new completion option with description.
+func NewDescribedCompletionOption(replacement, description string, isPartial bool) CompletionOption {
+	return &completionOption{replacement: replacement, isPartial: isPartial, description: description}
+}
+
 func (c *completionOption) String() string {
 	if len(c.label) > 0 {
 		return c.label
@@ -53,6 +66,76 @@ func (c *completionOption) IsPartial() bool {
 	return c.isPartial
 }
 
+func (c *completionOption) Description() string {
+	return c.description
+}
+
+// optionDescription returns the description of a completion option or an empty string if it has none.
+func optionDescription(option CompletionOption) string {
+	if described, ok := option.(DescribedCompletionOption); ok {
+		return described.Description()
+	}
+	return ""
+}
+
+// GroupedCompletionOption is implemented by completion options that are listed under a named group on double-tab.
+type GroupedCompletionOption interface {
+	CompletionOption
+	// Group returns the name of the group.
+	Group() string
+}
+
+type groupedCompletionOption struct {
+	CompletionOption
+	group string
+}
+
+func (c *groupedCompletionOption) Group() string {
+	return c.group
+}
+
+func (c *groupedCompletionOption) Description() string {
+	return optionDescription(c.CompletionOption)
+}
+
+// NewCompletionGroup assigns all options to the given group and returns them in the same order.
+func NewCompletionGroup(group string, options []CompletionOption) []CompletionOption {
+	grouped := make([]CompletionOption, len(options))
+	for i := range options {
+		grouped[i] = &groupedCompletionOption{options[i], group}
+	}
+	return grouped
+}
+
+// optionGroup returns the group of a completion option or an empty string if it has none.
+func optionGroup(option CompletionOption) string {
+	if grouped, ok := option.(GroupedCompletionOption); ok {
+		return grouped.Group()
+	}
+	return ""
+}
+
+// MergeCompletionOptions concatenates the options of several sources and keeps options of the same group together.
+func MergeCompletionOptions(lists ...[]CompletionOption) []CompletionOption {
+	groups := make([]string, 0)
+	byGroup := make(map[string][]CompletionOption)
+	for _, list := range lists {
+		for _, option := range list {
+			group := optionGroup(option)
+			if _, exists := byGroup[group]; !exists {
+				groups = append(groups, group)
+			}
+			byGroup[group] = append(byGroup[group], option)
+		}
+	}
+
+	merged := make([]CompletionOption, 0)
+	for _, group := range groups {
+		merged = append(merged, byGroup[group]...)
+	}
+	return merged
+}
+
 //TODO util func to easily map any slice/array/map to options with IsPartial flag
 
 // PrepareCompletionOptions returns a list of completion options with given isPartial flag.
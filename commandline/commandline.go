@@ -1,22 +1,29 @@
 package commandline
 
 import (
+	"context"
 	"fmt"
-	"sort"
+	"os"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/sbreitf1/go-console"
 )
 
 const (
 	maxAutoPrintListLen = 100
+	// descriptionSpaceLen denotes the number of spaces between completion options and their descriptions.
+	descriptionSpaceLen = 2
 )
 
 var (
 	// remember the last time Tab was pressed to detect double-tab.
 	lastTabPress  = time.Unix(0, 0)
 	doubleTabSpan = 250 * time.Millisecond
+
+	// groupHeaderStyle is used for the names of completion groups on double-tab.
+	groupHeaderStyle = console.Style{Bold: true}
 )
 
 // CommandHistoryHandler describes a function that returns a command from history at the given index.
@@ -24,18 +31,63 @@ var (
 // Index 0 denotes the latest command. nil is returned when the number of entries in history is exceeded. The index will never be negative.
 type CommandHistoryHandler func(index int) ([]string, bool)
 
+// CommandHistoryEventHandler describes a function that returns the command with the given event number from history, see HistoryEntry.Number.
+type CommandHistoryEventHandler func(number int) ([]string, bool)
+
 // ReadCommandOptions configures options and callbacks for ReadComman.
 type ReadCommandOptions struct {
 	// GetHistoryEntry denotes the handler for reading command history.
 	GetHistoryEntry CommandHistoryHandler
+	// HistoryPrefixSearch sets whether Up and Down only visit history entries that start with the typed text.
+	HistoryPrefixSearch bool
+	// ExpandHistory sets whether history references like !! are expanded, see ExpandHistory. Errors are returned as ErrHistoryExpansion.
+	ExpandHistory bool
+	// GetHistoryEvent denotes the handler for command numbers like !42 in history expansion. Can be nil to number the entries of GetHistoryEntry.
+	GetHistoryEvent CommandHistoryEventHandler
+	// SearchHistory denotes the handler for reverse history search with Ctrl+R. Can be nil to search using GetHistoryEntry.
+	SearchHistory CommandHistorySearchHandler
 	// GetCompletionOptions denotes the handler for auto completion.
 	GetCompletionOptions CommandCompletionHandler
+	// GetCompletionOptionsContext denotes a cancellable handler for auto completion that is preferred over GetCompletionOptions.
+	GetCompletionOptionsContext CommandCompletionContextHandler
+	// CompletionTimeout denotes the maximum duration of GetCompletionOptionsContext. Can be 0 for no timeout.
+	CompletionTimeout time.Duration
 	// PrintOptionsHandler denotes the handler to print options on double-tab.
 	PrintOptionsHandler PrintOptionsHandler
+	// CompletionMatcher decides which options match the typed command part and ranks them. Can be nil to use MatchPrefix.
+	CompletionMatcher CompletionMatcher
+	// MenuCompletion sets whether ambiguous completions open a selection menu below the line instead of printing the options on double-tab.
+	MenuCompletion bool
+	// Console denotes the console to read from and write to. Can be nil to use the default console.
+	Console *console.Console
+	// EditingMode denotes the key bindings for line editing. Defaults to EditingModeEmacs.
+	EditingMode EditingMode
+	// Keymap denotes the key bindings of editor actions. Can be nil to use DefaultKeymap.
+	Keymap *Keymap
+	// Suggester provides inline suggestions on consoles with color support. Can be nil to disable suggestions.
+	Suggester Suggester
+	// Highlighter returns styled spans to colorize the typed command on consoles with color support. Can be nil to disable highlighting.
+	Highlighter Highlighter
+
+	// killRing keeps killed text across multiple calls with the same options.
+	killRing *killRing
+	// resolveCompletion is preferred over the completion handlers and returns immediate options and a handler to call in the background.
+	resolveCompletion func(currentCommand []string, entryIndex int) ([]CompletionOption, CommandCompletionContextHandler)
 }
 
 // ReadCommand reads a command from console input and offers history, aswell as completion functionality.
 func ReadCommand(prompt string, opts *ReadCommandOptions) ([]string, error) {
+	return ReadCommandContext(context.Background(), prompt, opts)
+}
+
+// ReadCommandContext reads a command like ReadCommand, but returns the context error as soon as ctx is done.
+func ReadCommandContext(ctx context.Context, prompt string, opts *ReadCommandOptions) ([]string, error) {
+	cmd, _, err := readCommandContext(ctx, prompt, opts)
+	return cmd, err
+}
+
+// readCommandContext returns the parsed command and the command line as typed.
+func readCommandContext(ctx context.Context, prompt string, opts *ReadCommandOptions) ([]string, string, error) {
 	if opts == nil {
 		opts = &ReadCommandOptions{
 			PrintOptionsHandler: DefaultOptionsPrinter(),
@@ -43,27 +95,42 @@ func ReadCommand(prompt string, opts *ReadCommandOptions) ([]string, error) {
 	}
 
 	var cmd []string
-	err := console.WithReadKeyContext(func() error {
+	var input string
+	err := opts.Console.WithReadKeyContext(func() error {
 		var err error
-		cmd, err = readCommand(prompt, opts)
+		cmd, input, err = readCommand(ctx, prompt, opts)
 		return err
 	})
-	return cmd, err
+	return cmd, input, err
 }
 
-func readCommand(prompt string, opts *ReadCommandOptions) ([]string, error) {
+func readCommand(ctx context.Context, prompt string, opts *ReadCommandOptions) ([]string, string, error) {
 	var sb strings.Builder
 
 	for {
-		line, err := readCommandLine(&prompt, sb.String(), true, opts)
+		line, err := readCommandLine(ctx, &prompt, sb.String(), true, opts)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 
 		sb.WriteString(line)
 
-		if cmd, isComplete := ParseCommand(sb.String()); isComplete {
-			return cmd, nil
+		input := sb.String()
+		if cmd, isComplete := ParseCommand(input); isComplete {
+			if opts.ExpandHistory {
+				expanded, changed, err := ExpandHistory(input, opts.GetHistoryEntry, opts.GetHistoryEvent)
+				if err != nil {
+					opts.Console.Println(err.Error())
+					return nil, "", err
+				}
+				if changed {
+					// show the command that is actually executed
+					opts.Console.Println(expanded)
+					cmd, _ = ParseCommand(expanded)
+					input = expanded
+				}
+			}
+			return cmd, input, nil
 		}
 
 		// line break is part of command -> append to command because it has been omitted by the line reader
@@ -73,10 +140,8 @@ func readCommand(prompt string, opts *ReadCommandOptions) ([]string, error) {
 	}
 }
 
-func readCommandLine(prompt *string, currentCommand string, escapeHistory bool, opts *ReadCommandOptions) (string, error) {
-	if prompt != nil {
-		console.Printf("%s> ", *prompt)
-	}
+func readCommandLine(ctx context.Context, prompt *string, currentCommand string, escapeHistory bool, opts *ReadCommandOptions) (string, error) {
+	c := opts.Console
 
 	var cmdToString func([]string) string
 	if escapeHistory {
@@ -85,126 +150,273 @@ func readCommandLine(prompt *string, currentCommand string, escapeHistory bool,
 		cmdToString = func(cmd []string) string { return strings.Join(cmd, " ") }
 	}
 
-	var sb strings.Builder
-
-	lineLen := 0
-
-	putRune := func(r rune) {
-		sb.WriteRune(r)
-		console.Print(string(r))
-		lineLen++
+	line := newLineEditor(c)
+	if prompt != nil {
+		line.SetPrompt(*prompt + "> ")
 	}
+	line.SetHighlighter(opts.Highlighter, currentCommand)
+	line.Refresh()
 
-	putString := func(str string) {
-		sb.WriteString(str)
-		console.Print(str)
-		lineLen += len(str)
+	reprintLine := func() {
+		line.Invalidate()
+		line.Refresh()
 	}
 
-	clearLine := func() {
-		sb.Reset()
-		str1 := strings.Repeat("\b", lineLen)
-		str2 := strings.Repeat(" ", lineLen)
-		console.Printf("%s%s%s", str1, str2, str1)
-		lineLen = 0
+	historyIndex := -1
+	// originalLine holds the typed text while navigating the history
+	originalLine := ""
+	// findHistoryEntry returns the first history entry from start in the given direction that matches the typed text if required
+	findHistoryEntry := func(start, step int) (int, []string, bool) {
+		for i := start; i >= 0; i += step {
+			cmd, ok := opts.GetHistoryEntry(i)
+			if !ok {
+				return -1, nil, false
+			}
+			if !opts.HistoryPrefixSearch || strings.HasPrefix(cmdToString(cmd), originalLine) {
+				return i, cmd, true
+			}
+		}
+		return -1, nil, false
 	}
-
-	replaceLine := func(newLine string) {
-		clearLine()
-		putString(newLine)
+	search := opts.searchHandler(cmdToString)
+	if opts.killRing == nil {
+		opts.killRing = newKillRing()
+	}
+	ring := opts.killRing
+	suggester := opts.Suggester
+	if c.ColorDepth() == console.ColorDepthNone {
+		// suggestions cannot be distinguished from input without colors
+		suggester = nil
+	}
+	keymap := opts.Keymap
+	if keymap == nil {
+		keymap = defaultKeymap
+	}
+	var vi *viEditor
+	if opts.EditingMode == EditingModeVi {
+		vi = newViEditor()
 	}
 
-	reprintLine := func() {
-		if prompt == nil {
-			console.Printf("%s", sb.String())
+	// pendingEvents holds key events that still need to be processed
+	var pendingEvents []console.KeyEvent
+	// replayEvents denotes the number of events at the beginning of pendingEvents that repeat the last change in vi mode
+	replayEvents := 0
+	resized, stopResize := notifyResize()
+	defer stopResize()
+
+	for {
+		var event console.KeyEvent
+		replayed := false
+		if len(pendingEvents) > 0 {
+			event = pendingEvents[0]
+			pendingEvents = pendingEvents[1:]
+			if replayEvents > 0 {
+				replayed = true
+				replayEvents--
+			}
 		} else {
-			console.Printf("%s> %s", *prompt, sb.String())
+			var err error
+			event, err = readKeyEvent(ctx, c, resized, line.Refresh)
+			if err != nil {
+				if ctx.Err() != nil {
+					// leave the aborted line
+					line.Leave()
+				} else {
+					line.Finish()
+				}
+				return "", err
+			}
 		}
-	}
 
-	removeLastChar := func() {
-		if lineLen > 0 {
-			str := []rune(sb.String())
-			sb.Reset()
-			if len(str) > 0 {
-				sb.WriteString(string(str[:len(str)-1]))
+		handled := false
+		if vi != nil {
+			var result viResult
+			vi.replaying = replayed
+			event, result = vi.handle(event, line)
+			if result == viRepeat {
+				repetition := vi.repetition()
+				pendingEvents = append(repetition, pendingEvents...)
+				replayEvents += len(repetition)
 			}
-
-			console.Print("\b \b")
-			lineLen--
+			handled = result != viForward
 		}
-	}
 
-	historyIndex := -1
+		// kill and yank commands are chained if directly following each other
+		killed, yanked := false, false
 
-	for {
-		key, r, err := console.ReadKey()
-		if err != nil {
-			return "", err
+		action, ok := keymap.Action(event)
+		if handled || !ok {
+			action = ""
 		}
 
-		//TODO move caret along line
+		switch action {
+		case "":
+			// already processed by vi mode or not bound at all
+
+		case ActionSelfInsert:
+			if event.Key == console.KeySpace {
+				line.InsertAtCaret(" ")
+			} else if event.Key == 0 {
+				line.InsertAtCaret(string(event.Rune))
+			}
+
+		case ActionMoveLeft:
+			line.MoveCaretLeft()
+		case ActionMoveRight:
+			if !line.AcceptHint(false) {
+				line.MoveCaretRight()
+			}
+		case ActionMoveWordLeft:
+			line.MoveCaretWordLeft()
+		case ActionMoveWordRight:
+			if !line.AcceptHint(true) {
+				line.MoveCaretWordRight()
+			}
+		case ActionMoveToLineBegin:
+			line.MoveCaretToLineBegin()
+		case ActionMoveToLineEnd:
+			if !line.AcceptHint(false) {
+				line.MoveCaretToLineEnd()
+			}
 
-		switch key {
-		case console.KeyCtrlC:
+		case ActionDeleteLeft:
+			line.RemoveLeftOfCaret()
+		case ActionDeleteRight:
+			line.RemoveRightOfCaret()
+		case ActionClearLine:
+			line.Clear()
+
+		case ActionKillToLineEnd:
+			ring.Kill(line.KillToLineEnd(), false)
+			killed = true
+		case ActionKillToLineBegin:
+			ring.Kill(line.KillToLineBegin(), true)
+			killed = true
+		case ActionKillWordLeft:
+			ring.Kill(line.KillWordLeft(), true)
+			killed = true
+		case ActionYank:
+			yanked = ring.Yank(line)
+		case ActionYankPop:
+			yanked = ring.YankPop(line)
+		case ActionTransposeChars:
+			line.TransposeChars()
+
+		case ActionCancel:
+			line.Finish()
 			return "", ErrCtrlC()
 
-		case console.KeyEscape:
-			clearLine()
+		case ActionHistorySearch:
+			if search != nil {
+				line.Erase()
+				typed := line.String()
+				hs := &historySearch{console: c, search: search, cmdToString: cmdToString}
+				next, ok, err := hs.run(ctx, line)
+				if err != nil {
+					if ctx.Err() != nil {
+						c.Println()
+					}
+					return "", err
+				}
+				if ok {
+					pendingEvents = append(pendingEvents, next)
+				}
+				if hs.accepted {
+					// continue history navigation from the found entry
+					if historyIndex < 0 {
+						originalLine = typed
+					}
+					historyIndex = hs.matchIndex
+				}
+				reprintLine()
+			}
 
-		case console.KeyUp:
+		case ActionHistoryPrev:
 			if opts.GetHistoryEntry != nil {
-				if newCmd, ok := opts.GetHistoryEntry(historyIndex + 1); ok {
-					historyIndex++
-					replaceLine(cmdToString(newCmd))
+				if historyIndex < 0 {
+					originalLine = line.String()
+				}
+				if index, cmd, ok := findHistoryEntry(historyIndex+1, 1); ok {
+					historyIndex = index
+					line.Replace(cmdToString(cmd))
 				}
 			}
-		case console.KeyDown:
-			if opts.GetHistoryEntry != nil {
-				if historyIndex >= 0 {
-					historyIndex--
-
-					if historyIndex >= 0 {
-						if newCmd, ok := opts.GetHistoryEntry(historyIndex); ok {
-							replaceLine(cmdToString(newCmd))
-						} else {
-							// something seems to have changed -> return to initial state
-							historyIndex = -1
-							clearLine()
-						}
-					} else {
-						clearLine()
-					}
+		case ActionHistoryNext:
+			if opts.GetHistoryEntry != nil && historyIndex >= 0 {
+				if index, cmd, ok := findHistoryEntry(historyIndex-1, -1); ok {
+					historyIndex = index
+					line.Replace(cmdToString(cmd))
+				} else {
+					// return to the typed text, also if the history has changed in the meantime
+					historyIndex = -1
+					line.Replace(originalLine)
 				}
 			}
 
-		case console.KeyTab:
-			if opts.GetCompletionOptions != nil {
-				str := sb.String()
+		case ActionComplete:
+			if opts.GetCompletionOptions != nil || opts.GetCompletionOptionsContext != nil || opts.resolveCompletion != nil {
+				// complete the command part left of the caret
+				str := line.BeforeCaret()
 				cmd, _ := ParseCommand(fmt.Sprintf("%s%s", currentCommand, str))
 
 				if len(cmd) == 0 {
 					// append virtual entry to complete commands
 					cmd = []string{""}
 				} else {
-					if str[len(str)-1] == ' ' {
+					if len(str) > 0 && str[len(str)-1] == ' ' {
 						// new command part already started by whitespace, but not recognized as part of command
 						// -> append empty command part for processing
 						cmd = append(cmd, "")
 					}
 				}
 
+				// only context-aware handlers are called in the background
+				var allOptions, syncOptions []CompletionOption
+				var background CommandCompletionContextHandler
+				switch {
+				case opts.resolveCompletion != nil:
+					syncOptions, background = opts.resolveCompletion(cmd, len(cmd)-1)
+				case opts.GetCompletionOptionsContext != nil:
+					background = opts.GetCompletionOptionsContext
+				default:
+					syncOptions = opts.GetCompletionOptions(cmd, len(cmd)-1)
+				}
+
+				allOptions = syncOptions
+				if background != nil {
+					var next console.KeyEvent
+					var ok bool
+					var err error
+					allOptions, next, ok, err = awaitCompletion(ctx, line, background, cmd, len(cmd)-1, opts.CompletionTimeout)
+					if err != nil {
+						if ctx.Err() != nil {
+							line.Leave()
+						} else {
+							line.Finish()
+						}
+						return "", err
+					}
+					if ok {
+						// completion has been cancelled by typing
+						pendingEvents = append(pendingEvents, next)
+						break
+					}
+					if len(syncOptions) > 0 {
+						allOptions = MergeCompletionOptions(allOptions, syncOptions)
+					}
+				}
+
 				prefix := cmd[len(cmd)-1]
-				options := filterOptions(opts.GetCompletionOptions(cmd, len(cmd)-1), prefix)
+				options := filterOptions(allOptions, prefix, opts.CompletionMatcher)
+				// options that do not start with the prefix replace the command part from here
+				partStart := completionPartStart(currentCommand, str)
+				// menu requires a known terminal width for its layout
+				useMenu := opts.MenuCompletion && terminalWidth(c) > 0
 				if options != nil && len(options) > 0 {
-					if time.Since(lastTabPress) < doubleTabSpan {
+					if !useMenu && time.Since(lastTabPress) < doubleTabSpan {
 						if opts.PrintOptionsHandler != nil {
 							// double-tab detected -> print options
-							console.Println()
-
-							sort.Slice(options, func(i, j int) bool {
-								return options[i].String() < options[j].String()
-							})
+							line.Leave()
 							opts.PrintOptionsHandler(options)
 							reprintLine()
 						}
@@ -214,11 +426,10 @@ func readCommandLine(prompt *string, currentCommand string, escapeHistory bool,
 					} else {
 						if len(options) == 1 {
 							if len(options[0].Replacement()) > 0 {
-								suffix := Escape(options[0].Replacement()[len(prefix):])
-								putString(suffix)
+								insertCompletion(line, partStart, prefix, options[0].Replacement())
 
 								if !options[0].IsPartial() {
-									putRune(' ')
+									line.InsertAtCaret(" ")
 								}
 							} else {
 								// nothing changed? start double-tab combo
@@ -226,10 +437,27 @@ func readCommandLine(prompt *string, currentCommand string, escapeHistory bool,
 							}
 
 						} else {
-							longestCommonPrefix := findLongestCommonPrefix(options)
-							suffix := Escape(longestCommonPrefix[len(prefix):])
-							if len(suffix) > 0 {
-								putString(suffix)
+							longestCommonPrefix := findLongestCommonPrefix(options, false)
+							if !strings.HasPrefix(longestCommonPrefix, prefix) {
+								// options have been matched regardless of case or not at their beginning
+								longestCommonPrefix = findLongestCommonPrefix(options, true)
+							}
+							if len(longestCommonPrefix) > len(prefix) && hasPrefixFold(longestCommonPrefix, prefix) {
+								insertCompletion(line, partStart, prefix, longestCommonPrefix)
+							} else if useMenu {
+								menu := &menuCompletion{console: c, options: options, prefix: prefix, partStart: partStart}
+								next, ok, err := menu.run(ctx, line)
+								if err != nil {
+									if ctx.Err() != nil {
+										line.Leave()
+									} else {
+										line.Finish()
+									}
+									return "", err
+								}
+								if ok {
+									pendingEvents = append(pendingEvents, next)
+								}
 							} else {
 								// nothing changed? start double-tab combo
 								lastTabPress = time.Now()
@@ -242,54 +470,74 @@ func readCommandLine(prompt *string, currentCommand string, escapeHistory bool,
 				}
 			}
 
-		case console.KeyEnter:
-			console.Println()
-			return sb.String(), nil
-
-		case console.KeyBackspace:
-			removeLastChar()
-
-		case console.KeySpace:
-			putRune(' ')
-
-		case 0:
-			putRune(r)
+		case ActionAcceptLine:
+			// caret might be somewhere in the middle of the line
+			line.MoveCaretToLineEnd()
+			line.Leave()
+			return line.String(), nil
 
 		default:
-			// ignore unknown special keys
+			if widget, ok := keymap.widget(action); ok {
+				widget(line)
+			}
+			// unknown actions are ignored
+		}
+
+		ring.EndAction(killed, yanked)
+		if vi != nil && vi.normal {
+			vi.clampCaret(line)
+		}
+		if suggester != nil {
+			hint := ""
+			if len(currentCommand) == 0 && line.Caret() == line.Len() {
+				// suggestions are only displayed while typing at the end of the first line
+				hint = suggestionHint(suggester, line.String())
+			}
+			line.SetHint(hint)
 		}
+		line.Refresh()
 	}
 }
 
-func filterOptions(options []CompletionOption, prefix string) []CompletionOption {
-	if options == nil {
-		return nil
+// completionPartStart returns the position in the line at which the command part left of the caret begins.
+func completionPartStart(currentCommand, beforeCaret string) int {
+	str := currentCommand + beforeCaret
+	tokens, _ := scanCommand(str)
+	if len(tokens) == 0 || tokens[len(tokens)-1].end < len(str) {
+		// new command part begins at the caret
+		return utf8.RuneCountInString(beforeCaret)
 	}
+	start := max(tokens[len(tokens)-1].start-len(currentCommand), 0)
+	return utf8.RuneCountInString(beforeCaret[:start])
+}
 
-	filtered := make([]CompletionOption, 0)
-	for _, c := range options {
-		if strings.HasPrefix(c.Replacement(), prefix) {
-			filtered = append(filtered, c)
-		}
+// insertCompletion inserts a completed command part at the caret and replaces the part from start if it does not begin with prefix.
+func insertCompletion(line *lineEditor, start int, prefix, completion string) {
+	if strings.HasPrefix(completion, prefix) {
+		line.InsertAtCaret(Escape(completion[len(prefix):]))
+		return
 	}
-	return filtered
+	line.RemoveRange(start, line.Caret())
+	line.InsertAtCaret(Escape(completion))
 }
 
-func findLongestCommonPrefix(options []CompletionOption) string {
+// findLongestCommonPrefix returns the longest prefix of all replacements. The case of the first option is used if ignoreCase is set.
+func findLongestCommonPrefix(options []CompletionOption, ignoreCase bool) string {
+	hasPrefix := strings.HasPrefix
+	if ignoreCase {
+		hasPrefix = hasPrefixFold
+	}
+
 	if len(options) == 0 {
 		return ""
 	}
 
 	longestCommonPrefix := ""
-	for i := 1; ; i++ {
-		if len(options[0].Replacement()) < i {
-			// prefix cannot be any longer
-			return longestCommonPrefix
-		}
-
-		prefix := options[0].Replacement()[:i]
+	// grow prefix by whole grapheme clusters to never split characters
+	for _, cluster := range console.Graphemes(options[0].Replacement()) {
+		prefix := longestCommonPrefix + cluster
 		for _, c := range options {
-			if !strings.HasPrefix(c.Replacement(), prefix) {
+			if !hasPrefix(c.Replacement(), prefix) {
 				// the next prefix would not be valid for all options
 				return longestCommonPrefix
 			}
@@ -297,70 +545,52 @@ func findLongestCommonPrefix(options []CompletionOption) string {
 
 		longestCommonPrefix = prefix
 	}
+	// prefix cannot be any longer
+	return longestCommonPrefix
 }
 
-// ParseCommand parses a command input with escape sequences, single quotes and double quotes. The return parameter isComplete is false when a quote or escape sequence is not closed.
-func ParseCommand(str string) (parts []string, isComplete bool) {
-	cmd := make([]string, 0)
-
-	var sb strings.Builder
-	escape := false
-	doubleQuote := false
-	singleQuote := false
-
-	for _, r := range str {
-		if singleQuote {
-			if r == '\'' {
-				singleQuote = false
-			} else {
-				sb.WriteRune(r)
-			}
-
-		} else if doubleQuote {
-			if escape {
-				if r != '\\' && r != '$' && r != '"' {
-					// consume escape character only for actual escape sequences
-					sb.WriteRune('\\')
-				}
-				sb.WriteRune(r)
-				escape = false
+// readKeyEvent reads the next key event from c and calls onResize for every signal received from resized while waiting.
+func readKeyEvent(ctx context.Context, c *console.Console, resized <-chan os.Signal, onResize func()) (console.KeyEvent, error) {
+	if resized == nil {
+		return c.ReadKeyEventContext(ctx)
+	}
 
-			} else {
-				if r == '"' {
-					doubleQuote = false
-				} else if r == '\\' {
-					escape = true
-				} else {
-					sb.WriteRune(r)
-				}
+	for {
+		readCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		interrupted := make(chan bool, 1)
+		go func() {
+			select {
+			case <-resized:
+				interrupted <- true
+				cancel()
+			case <-done:
+				interrupted <- false
 			}
-		} else if escape {
-			sb.WriteRune(r)
-			escape = false
+		}()
 
-		} else {
-			if r == '\\' {
-				escape = true
-			} else if r == '\'' {
-				singleQuote = true
-			} else if r == '"' {
-				doubleQuote = true
-			} else if r == ' ' {
-				if sb.Len() > 0 {
-					cmd = append(cmd, sb.String())
-					sb.Reset()
-				}
-			} else {
-				sb.WriteRune(r)
-			}
+		event, err := c.ReadKeyEventContext(readCtx)
+		close(done)
+		if <-interrupted && err != nil && ctx.Err() == nil {
+			cancel()
+			onResize()
+			continue
 		}
+		cancel()
+		return event, err
 	}
+}
 
-	if len(sb.String()) > 0 {
-		cmd = append(cmd, sb.String())
+// ParseCommand parses a command input with escape sequences, single quotes and double quotes. The return parameter isComplete is false when a quote or escape sequence is not closed.
+func ParseCommand(str string) (parts []string, isComplete bool) {
+	tokens, isComplete := scanCommand(str)
+	cmd := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		if len(token.value) > 0 {
+			cmd = append(cmd, token.value)
+		}
 	}
-
-	return cmd, (!escape && !singleQuote && !doubleQuote)
+	return cmd, isComplete
 }
 
 // GetCommandString is the inverse function of Parse() and outputs a single string equal to the given command.
@@ -413,15 +643,40 @@ type Environment struct {
 	ExecUnknownCommand ExecUnknownCommandHandler
 	// UnknownCommandCompletionHandler is used for completion of unknown commands.
 	CompleteUnknownCommand CommandCompletionHandler
+	// CompletionSources denotes additional completion handlers whose options are merged into the options of all commands.
+	CompletionSources []CommandCompletionHandler
 	// ErrorHandler is called to handle errors returned from commands. Use RecoverPanickedCommands to also handle panics here.
 	ErrorHandler CommandErrorHandler
 	// RecoverPanickedCommands sets whether the ErrorHandler is also called for panics.
 	RecoverPanickedCommands bool
 	// UseCommandNameCompletion denotes whether completion is available for command names.
 	UseCommandNameCompletion bool
+	// CompletionMatcher decides which options match the typed command part and ranks them. Can be nil to use MatchPrefix.
+	CompletionMatcher CompletionMatcher
+	// MenuCompletion sets whether ambiguous completions open a selection menu below the line instead of printing the options on double-tab.
+	MenuCompletion bool
+	// CompletionTimeout denotes the maximum duration of completion handlers implementing ContextCompletionCommand. Can be 0 for no timeout.
+	CompletionTimeout time.Duration
+	// HistoryPrefixSearch sets whether Up and Down only visit history entries that start with the typed text.
+	HistoryPrefixSearch bool
+	// HistoryExpansion sets whether history references like !! and ^old^new are expanded before execution.
+	HistoryExpansion bool
+	// Console denotes the console to read commands from and to print messages to. Can be nil to use the default console.
+	Console *console.Console
+	// EditingMode denotes the key bindings for line editing. Defaults to EditingModeEmacs.
+	EditingMode EditingMode
+	// Keymap denotes the key bindings of editor actions. Can be nil to use DefaultKeymap.
+	Keymap *Keymap
+	// Suggester provides inline suggestions while typing. Suggests from command history by default, can be nil to disable suggestions.
+	Suggester Suggester
+	// Highlighter colorizes the typed command. Can be nil to disable highlighting.
+	Highlighter Highlighter
 
 	history  CommandHistory
 	commands map[string]Command
+	killRing *killRing
+	// skipHistory is set by commands that have edited the history to not save themselves afterwards.
+	skipHistory bool
 }
 
 // PromptHandler defines a function that returns the current command line prompt.
@@ -437,27 +692,40 @@ type CommandErrorHandler func(cmd string, args []string, err error) error
 
 // NewEnvironment returns a new command line environment.
 func NewEnvironment() *Environment {
-	return &Environment{
-		Prompt:       func() string { return "cle" },
-		PrintOptions: DefaultOptionsPrinter(),
-		ExecUnknownCommand: func(cmd string, _ []string) error {
-			_, err := console.Printlnf("Unknown command %q", cmd)
-			return err
-		},
-		CompleteUnknownCommand: nil,
-		ErrorHandler: func(_ string, _ []string, err error) error {
-			if IsErrCommandPanicked(err) {
-				console.Printlnf("PANIC: %s", err.Error())
-			} else {
-				console.Printlnf("ERROR: %s", err.Error())
-			}
-			return nil
-		},
+	env := &Environment{
+		Prompt:                   func() string { return "cle" },
+		CompleteUnknownCommand:   nil,
 		RecoverPanickedCommands:  true,
 		UseCommandNameCompletion: true,
 		history:                  NewCommandHistory(100),
 		commands:                 make(map[string]Command),
+		killRing:                 newKillRing(),
+	}
+	// suggestions always use the currently configured history
+	env.Suggester = SuggesterFunc(func(input string) (string, bool) {
+		return suggestFromHistory(env.history, input)
+	})
+	env.Highlighter = NewCommandHighlighter(func(name string) bool {
+		_, exists := env.commands[name]
+		return exists
+	})
+	// default handlers always print to the currently configured console
+	env.PrintOptions = func(options []CompletionOption) {
+		NewOptionsPrinter(env.Console)(options)
+	}
+	env.ExecUnknownCommand = func(cmd string, _ []string) error {
+		_, err := env.Console.Printlnf("Unknown command %q", cmd)
+		return err
+	}
+	env.ErrorHandler = func(_ string, _ []string, err error) error {
+		if IsErrCommandPanicked(err) {
+			env.Console.Printlnf("PANIC: %s", err.Error())
+		} else {
+			env.Console.Printlnf("ERROR: %s", err.Error())
+		}
+		return nil
 	}
+	return env
 }
 
 // SetStaticPrompt sets a constant prompt to display for command input.
@@ -465,6 +733,11 @@ func (b *Environment) SetStaticPrompt(prompt string) {
 	b.Prompt = func() string { return prompt }
 }
 
+// SetHistory replaces the command history, e.g. by a history from NewFileCommandHistory.
+func (b *Environment) SetHistory(history CommandHistory) {
+	b.history = history
+}
+
 func (b *Environment) prompt() string {
 	if b.Prompt == nil {
 		return ""
@@ -488,36 +761,96 @@ func (b *Environment) UnregisterCommand(commandName string) bool {
 
 // ReadCommand reads a command for the configured environment.
 func (b *Environment) ReadCommand() ([]string, error) {
-	return b.readCommand(ReadCommand)
+	return b.ReadCommandContext(context.Background())
 }
 
-func (b *Environment) readCommand(handler func(prompt string, opts *ReadCommandOptions) ([]string, error)) ([]string, error) {
+// ReadCommandContext reads a command for the configured environment and returns the context error as soon as ctx is done.
+func (b *Environment) ReadCommandContext(ctx context.Context) ([]string, error) {
+	cmd, input, err := b.readCommand(ctx)
+	if err != nil {
+		return nil, err
+	}
+	b.putHistory(HistoryEntry{Command: cmd, Input: input})
+	return cmd, nil
+}
+
+// readCommand returns the parsed command and the command line as typed.
+func (b *Environment) readCommand(ctx context.Context) ([]string, string, error) {
 	opts := &ReadCommandOptions{
 		GetHistoryEntry:      b.history.GetHistoryEntry,
+		HistoryPrefixSearch:  b.HistoryPrefixSearch,
+		ExpandHistory:        b.HistoryExpansion,
+		SearchHistory:        b.history.Search,
 		GetCompletionOptions: b.GetCompletionOptions,
+		CompletionTimeout:    b.CompletionTimeout,
 		PrintOptionsHandler:  b.PrintOptions,
+		CompletionMatcher:    b.CompletionMatcher,
+		MenuCompletion:       b.MenuCompletion,
+		Console:              b.Console,
+		EditingMode:          b.EditingMode,
+		Keymap:               b.Keymap,
+		Suggester:            b.Suggester,
+		Highlighter:          b.Highlighter,
+		killRing:             b.killRing,
+		resolveCompletion:    b.resolveCompletion,
+	}
+	if history, ok := b.history.(MetadataCommandHistory); ok {
+		opts.GetHistoryEvent = func(number int) ([]string, bool) {
+			if index, ok := history.FindEvent(number); ok {
+				return history.GetHistoryEntry(index)
+			}
+			return nil, false
+		}
+	}
+	cmd, input, err := readCommandContext(ctx, b.prompt(), opts)
+	for IsErrHistoryExpansion(err) {
+		// error has already been printed -> read again
+		cmd, input, err = readCommandContext(ctx, b.prompt(), opts)
 	}
-	cmd, err := handler(b.prompt(), opts)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
+	return cmd, input, nil
+}
 
-	if len(cmd) > 0 && len(cmd[0]) > 0 {
-		b.history.Put(cmd)
+// putHistory saves an entry to history. Metadata is only kept if supported by the history.
+func (b *Environment) putHistory(entry HistoryEntry) {
+	if len(entry.Command) == 0 || len(entry.Command[0]) == 0 {
+		return
+	}
+	if h, ok := b.history.(MetadataCommandHistory); ok {
+		h.PutEntry(entry)
+	} else {
+		b.history.Put(entry.Command)
 	}
-	return cmd, nil
 }
 
 // Run reads and processes commands until an error is returned. Use ErrExit to gracefully stop processing.
 func (b *Environment) Run() error {
+	return b.RunContext(context.Background())
+}
+
+// RunContext reads and processes commands like Run, but returns the context error as soon as ctx is done.
+func (b *Environment) RunContext(ctx context.Context) error {
 	for {
-		cmd, err := b.readCommand(ReadCommand)
+		cmd, input, err := b.readCommand(ctx)
 		if err != nil {
 			return err
 		}
 
 		if len(cmd) > 0 {
-			if err := b.ExecCommand(cmd[0], cmd[1:]); err != nil {
+			entry := HistoryEntry{Command: cmd, Input: input, Time: time.Now()}
+			entry.WorkingDir, _ = os.Getwd()
+			b.skipHistory = false
+			err := b.ExecCommand(cmd[0], cmd[1:])
+			entry.Duration = time.Since(entry.Time)
+			entry.Errored = err != nil && !IsErrExit(err)
+			// command is added to history after execution to know the result
+			if !b.skipHistory {
+				b.putHistory(entry)
+			}
+
+			if err != nil {
 				if IsErrExit(err) {
 					return nil
 				}
@@ -532,12 +865,49 @@ func (b *Environment) Run() error {
 
 // GetCompletionOptions returns completion options for the given command. This method can be used as callback for ReadCommand.
 func (b *Environment) GetCompletionOptions(currentCommand []string, entryIndex int) []CompletionOption {
+	return b.GetCompletionOptionsContext(context.Background(), currentCommand, entryIndex)
+}
+
+// GetCompletionOptionsContext returns completion options like GetCompletionOptions and passes ctx to commands implementing ContextCompletionCommand.
+func (b *Environment) GetCompletionOptionsContext(ctx context.Context, currentCommand []string, entryIndex int) []CompletionOption {
+	options := b.commandCompletionOptions(ctx, currentCommand, entryIndex)
+	if len(b.CompletionSources) == 0 {
+		return options
+	}
+
+	lists := [][]CompletionOption{options}
+	for _, source := range b.CompletionSources {
+		lists = append(lists, source(currentCommand, entryIndex))
+	}
+	return MergeCompletionOptions(lists...)
+}
+
+// resolveCompletion returns the options that are available right away and a context-aware handler to call in the background.
+func (b *Environment) resolveCompletion(currentCommand []string, entryIndex int) ([]CompletionOption, CommandCompletionContextHandler) {
+	if entryIndex > 0 {
+		if cmd, ok := b.commands[currentCommand[0]].(ContextCompletionCommand); ok {
+			lists := make([][]CompletionOption, 0, len(b.CompletionSources))
+			for _, source := range b.CompletionSources {
+				lists = append(lists, source(currentCommand, entryIndex))
+			}
+			return MergeCompletionOptions(lists...), cmd.GetCompletionOptionsContext
+		}
+	}
+	return b.GetCompletionOptions(currentCommand, entryIndex), nil
+}
+
+// commandCompletionOptions returns the completion options for command names or from the completion handler of the command.
+func (b *Environment) commandCompletionOptions(ctx context.Context, currentCommand []string, entryIndex int) []CompletionOption {
 	if entryIndex == 0 {
 		if b.UseCommandNameCompletion {
 			// completion for command
 			options := make([]CompletionOption, 0)
-			for name := range b.commands {
-				options = append(options, &completionOption{replacement: name})
+			for name, cmd := range b.commands {
+				option := &completionOption{replacement: name}
+				if described, ok := cmd.(DescribedCommand); ok {
+					option.description = described.Description()
+				}
+				options = append(options, option)
 			}
 			return options
 		}
@@ -552,6 +922,9 @@ func (b *Environment) GetCompletionOptions(currentCommand []string, entryIndex i
 		return nil
 	}
 
+	if contextCmd, ok := cmd.(ContextCompletionCommand); ok {
+		return contextCmd.GetCompletionOptionsContext(ctx, currentCommand, entryIndex)
+	}
 	return cmd.GetCompletionOptions(currentCommand, entryIndex)
 }
 
@@ -590,11 +963,16 @@ type PrintOptionsHandler func([]CompletionOption)
 //
 // This method will ask the user for large lists to confirm printin.
 func DefaultOptionsPrinter() PrintOptionsHandler {
+	return NewOptionsPrinter(nil)
+}
+
+// NewOptionsPrinter returns a function like DefaultOptionsPrinter that prints to the given console. Can be nil to use the default console.
+func NewOptionsPrinter(c *console.Console) PrintOptionsHandler {
 	return func(options []CompletionOption) {
 		if len(options) > maxAutoPrintListLen {
-			console.Printlnf("  print all %d options? (y/N)", len(options))
+			c.Printlnf("  print all %d options? (y/N)", len(options))
 			// assume is only called during command reading here (keyboard needs to be prepared)
-			_, r, err := console.ReadKey()
+			_, r, err := c.ReadKey()
 			if err != nil {
 				return
 			}
@@ -604,7 +982,56 @@ func DefaultOptionsPrinter() PrintOptionsHandler {
 			}
 		}
 
-		console.PrintList(options)
+		for len(options) > 0 {
+			// print consecutive options of the same group below its name
+			group := optionGroup(options[0])
+			end := 1
+			for end < len(options) && optionGroup(options[end]) == group {
+				end++
+			}
+			if len(group) > 0 {
+				c.PrintlnStyled(groupHeaderStyle, group+":")
+			}
+			if hasDescriptions(options[:end]) {
+				printDescribedOptions(c, options[:end])
+			} else {
+				c.PrintList(options[:end])
+			}
+			options = options[end:]
+		}
+	}
+}
+
+// hasDescriptions returns true if at least one option has a description.
+func hasDescriptions(options []CompletionOption) bool {
+	for _, option := range options {
+		if len(optionDescription(option)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// printDescribedOptions prints every option in a separate row with aligned and shortened descriptions.
+func printDescribedOptions(c *console.Console, options []CompletionOption) {
+	labelWidth := 0
+	for _, option := range options {
+		labelWidth = max(labelWidth, console.StringWidth(option.String()))
+	}
+	width := terminalWidth(c)
+
+	for _, option := range options {
+		label := option.String()
+		description := optionDescription(option)
+		if width > 0 {
+			description = strings.TrimRight(truncateWidth(description, width-1-labelWidth-descriptionSpaceLen), " ")
+		}
+		if len(description) == 0 {
+			c.Println(label)
+			continue
+		}
+		padding := strings.Repeat(" ", labelWidth-console.StringWidth(label)+descriptionSpaceLen)
+		c.Println(label + padding + description)
 	}
 }
 
@@ -618,6 +1045,13 @@ type Command interface {
 	Exec(args []string) error
 }
 
+// DescribedCommand is implemented by commands that have a description to display on command name completion.
+type DescribedCommand interface {
+	Command
+	// Description returns a short explanation of the command.
+	Description() string
+}
+
 // ExecCommandHandler is called when processing a command. Return ErrExit to gracefully stop processing.
 type ExecCommandHandler func(args []string) error
 
@@ -625,11 +1059,15 @@ type customCommand struct {
 	name              string
 	completionHandler CommandCompletionHandler
 	execHandler       ExecCommandHandler
+	description       string
 }
 
 func (c *customCommand) Name() string {
 	return c.name
 }
+func (c *customCommand) Description() string {
+	return c.description
+}
 func (c *customCommand) GetCompletionOptions(currentCommand []string, entryIndex int) []CompletionOption {
 	if c.completionHandler != nil {
 		return c.completionHandler(currentCommand, entryIndex)
@@ -645,15 +1083,20 @@ func (c *customCommand) Exec(args []string) error {
 
 // NewExitCommand returns a named command to stop command line processing.
 func NewExitCommand(name string) Command {
-	return &customCommand{name, func([]string, int) []CompletionOption { return nil }, func([]string) error { return ErrExit() }}
+	return &customCommand{name, func([]string, int) []CompletionOption { return nil }, func([]string) error { return ErrExit() }, ""}
 }
 
 // NewParameterlessCommand returns a named command that takes no parameters.
 func NewParameterlessCommand(name string, handler ExecCommandHandler) Command {
-	return &customCommand{name, func([]string, int) []CompletionOption { return nil }, handler}
+	return &customCommand{name, func([]string, int) []CompletionOption { return nil }, handler, ""}
 }
 
 // NewCustomCommand returns a named command with completion and execution handler.
 func NewCustomCommand(name string, completionHandler CommandCompletionHandler, execHandler ExecCommandHandler) Command {
-	return &customCommand{name, completionHandler, execHandler}
+	return &customCommand{name, completionHandler, execHandler, ""}
+}
+
+// NewDescribedCommand returns a named command like NewCustomCommand with a description that is displayed on command name completion.
+func NewDescribedCommand(name, description string, completionHandler CommandCompletionHandler, execHandler ExecCommandHandler) Command {
+	return &customCommand{name, completionHandler, execHandler, description}
 }
@@ -1,8 +1,11 @@
 package commandline
 
 import (
+	"context"
+	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/sbreitf1/go-console"
 	"github.com/sbreitf1/go-console/consoletest"
@@ -52,6 +55,28 @@ func TestReadCommandUTF8(t *testing.T) {
 	})
 }
 
+func TestReadCommandGraphemeClusters(t *testing.T) {
+	consoletest.WithMocks(func(input *consoletest.MockInput) {
+		// backspace removes the whole emoji sequence and the accented letter
+		input.PutString("日本 \U0001F469\u200D\U0001F4BB")
+		input.PutKeys(console.KeyBackspace, console.KeyLeft, console.KeyLeft)
+		input.PutString("e\u0301")
+		input.PutKeys(console.KeyBackspace)
+		input.PutString("x\n")
+		cmd, err := ReadCommand("", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"日x本"}, cmd)
+		input.AssertBufferConsumed(t)
+	})
+}
+
+func TestFindLongestCommonPrefix(t *testing.T) {
+	assert.Equal(t, "", findLongestCommonPrefix(PrepareCompletionOptions([]string{"äb", "öb"}, false), false))
+	assert.Equal(t, "日本", findLongestCommonPrefix(PrepareCompletionOptions([]string{"日本語", "日本"}, false), false))
+	// accented letter is not split from its base letter
+	assert.Equal(t, "caf", findLongestCommonPrefix(PrepareCompletionOptions([]string{"cafe\u0301", "cafe"}, false), false))
+}
+
 func TestReadCommandEscape(t *testing.T) {
 	consoletest.WithMocks(func(input *consoletest.MockInput) {
 		input.PutString("foobar")
@@ -64,6 +89,205 @@ func TestReadCommandEscape(t *testing.T) {
 	})
 }
 
+func TestReadCommandCaretMovement(t *testing.T) {
+	consoletest.WithMocks(func(input *consoletest.MockInput) {
+		input.PutString("fo br")
+		input.PutKeys(console.KeyLeft, console.KeyLeft, console.KeyLeft)
+		input.PutString("o")
+		input.PutKeys(console.KeyRight, console.KeyRight)
+		input.PutString("a")
+		input.PutKeys(console.KeyHome)
+		input.PutString("x")
+		input.PutKeys(console.KeyEnd)
+		input.PutString("!\n")
+		cmd, err := ReadCommand("", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"xfoo", "bar!"}, cmd)
+		input.AssertBufferConsumed(t)
+	})
+}
+
+func TestReadCommandDelete(t *testing.T) {
+	consoletest.WithMocks(func(input *consoletest.MockInput) {
+		input.PutString("foo baar")
+		input.PutKeys(console.KeyLeft, console.KeyLeft, console.KeyLeft, console.KeyDelete)
+		input.PutKeys(console.KeyEnd, console.KeyDelete, console.KeyHome, console.KeyBackspace, console.KeyDelete)
+		input.PutString("\n")
+		cmd, err := ReadCommand("", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"oo", "bar"}, cmd)
+		input.AssertBufferConsumed(t)
+	})
+}
+
+func TestReadCommandCompletionInsideLine(t *testing.T) {
+	consoletest.WithMocks(func(input *consoletest.MockInput) {
+		input.PutString("p f")
+		input.PutKeys(console.KeyLeft, console.KeyLeft)
+		input.PutString("\t\n")
+
+		cmd, err := ReadCommand("", &ReadCommandOptions{
+			GetCompletionOptions: func(cmd []string, index int) []CompletionOption {
+				return PrepareCompletionOptions([]string{"print"}, false)
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"print", "f"}, cmd)
+		input.AssertBufferConsumed(t)
+	})
+}
+
+func TestReadCommandWordMovement(t *testing.T) {
+	consoletest.WithMocks(func(input *consoletest.MockInput) {
+		input.PutString("foo bar blub")
+		input.PutKeyEvents(console.KeyEvent{Key: console.KeyLeft, Modifiers: console.ModCtrl})
+		input.PutString("x")
+		input.PutKeyEvents(console.KeyEvent{Rune: 'b', Modifiers: console.ModAlt}, console.KeyEvent{Rune: 'b', Modifiers: console.ModAlt})
+		input.PutString("y")
+		input.PutKeyEvents(console.KeyEvent{Rune: 'f', Modifiers: console.ModAlt})
+		input.PutString("z")
+		input.PutKeyEvents(console.KeyEvent{Key: console.KeyRight, Modifiers: console.ModCtrl})
+		input.PutString("!\n")
+		cmd, err := ReadCommand("", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"foo", "ybarz", "xblub!"}, cmd)
+		input.AssertBufferConsumed(t)
+	})
+}
+
+func TestReadCommandEmacsMovement(t *testing.T) {
+	consoletest.WithMocks(func(input *consoletest.MockInput) {
+		input.PutString("ob")
+		input.PutKeys(console.KeyCtrlA)
+		input.PutString("f")
+		input.PutKeys(console.KeyCtrlF)
+		input.PutString("o")
+		input.PutKeys(console.KeyCtrlE)
+		input.PutString("ar")
+		input.PutKeys(console.KeyCtrlB, console.KeyCtrlB)
+		input.PutString(" ")
+		input.PutKeys(console.KeyCtrlT)
+		input.PutString("\n")
+		cmd, err := ReadCommand("", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"fooba", "r"}, cmd)
+		input.AssertBufferConsumed(t)
+	})
+}
+
+func TestReadCommandKillAndYank(t *testing.T) {
+	consoletest.WithMocks(func(input *consoletest.MockInput) {
+		input.PutString("foo bar baz")
+		// consecutive kills are merged
+		input.PutKeys(console.KeyCtrlW, console.KeyCtrlW)
+		input.PutKeys(console.KeyCtrlY, console.KeyCtrlY)
+		input.PutString("\n")
+		cmd, err := ReadCommand("", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"foo", "bar", "bazbar", "baz"}, cmd)
+		input.AssertBufferConsumed(t)
+	})
+}
+
+func TestReadCommandKillRing(t *testing.T) {
+	consoletest.WithMocks(func(input *consoletest.MockInput) {
+		input.PutString("one two")
+		input.PutKeys(console.KeyCtrlU)
+		input.PutString("three")
+		input.PutKeys(console.KeyHome, console.KeyCtrlK)
+		input.PutString("x ")
+		// yank latest entry, then rotate to the older one
+		input.PutKeys(console.KeyCtrlY)
+		input.PutKeyEvents(console.KeyEvent{Rune: 'y', Modifiers: console.ModAlt})
+		input.PutString("\n")
+		cmd, err := ReadCommand("", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"x", "one", "two"}, cmd)
+		input.AssertBufferConsumed(t)
+	})
+}
+
+func TestReadCommandYankPopWithoutYank(t *testing.T) {
+	consoletest.WithMocks(func(input *consoletest.MockInput) {
+		input.PutString("foo bar")
+		input.PutKeys(console.KeyCtrlW)
+		input.PutString("x")
+		input.PutKeyEvents(console.KeyEvent{Rune: 'y', Modifiers: console.ModAlt})
+		input.PutString("\n")
+		cmd, err := ReadCommand("", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"foo", "x"}, cmd)
+		input.AssertBufferConsumed(t)
+	})
+}
+
+func TestReadCommandHistoryRestoresTypedText(t *testing.T) {
+	consoletest.WithMocks(func(input *consoletest.MockInput) {
+		input.PutString("foo")
+		input.PutKeys(console.KeyUp, console.KeyUp, console.KeyDown, console.KeyDown, console.KeyDown)
+		input.PutString("\n")
+		cmd, err := ReadCommand("", &ReadCommandOptions{GetHistoryEntry: newTestHistory("deploy prod", "status")})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"foo"}, cmd)
+		input.AssertBufferConsumed(t)
+	})
+}
+
+func TestReadCommandHistoryPrefixSearch(t *testing.T) {
+	consoletest.WithMocks(func(input *consoletest.MockInput) {
+		opts := &ReadCommandOptions{
+			GetHistoryEntry:     newTestHistory("deploy prod", "status", "deploy staging", "deploy test", "help"),
+			HistoryPrefixSearch: true,
+		}
+
+		input.PutString("deploy ")
+		input.PutKeys(console.KeyUp, console.KeyUp, console.KeyUp, console.KeyUp, console.KeyDown)
+		input.PutString("\n")
+		cmd, err := ReadCommand("", opts)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"deploy", "staging"}, cmd)
+
+		input.PutString("deploy s")
+		input.PutKeys(console.KeyUp, console.KeyUp, console.KeyDown)
+		input.PutString("x\n")
+		cmd, err = ReadCommand("", opts)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"deploy", "sx"}, cmd)
+
+		// empty input visits all entries
+		input.PutKeys(console.KeyUp, console.KeyUp)
+		input.PutString("\n")
+		cmd, err = ReadCommand("", opts)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"status"}, cmd)
+		input.AssertBufferConsumed(t)
+	})
+}
+
+func newTestHistory(entries ...string) CommandHistoryHandler {
+	return func(index int) ([]string, bool) {
+		if index < len(entries) {
+			cmd, _ := ParseCommand(entries[index])
+			return cmd, true
+		}
+		return nil, false
+	}
+}
+
+func TestReadCommandContext(t *testing.T) {
+	consoletest.WithMocks(func(input *consoletest.MockInput) {
+		input.PutString("foo")
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		cmd, err := ReadCommandContext(ctx, "", nil)
+		assert.Equal(t, context.DeadlineExceeded, err)
+		assert.Nil(t, cmd)
+		input.AssertBufferConsumed(t)
+		// terminal has been restored
+		assert.NoError(t, input.BeginReadKey())
+	})
+}
+
 func TestReadMultilineCommand(t *testing.T) {
 	consoletest.WithMocks(func(input *consoletest.MockInput) {
 		input.PutString("foo \"foo\nbar\" foo\\\nbar\n")
@@ -173,6 +397,36 @@ func TestCommandLineEnvironmentLongestPrefixCompletion(t *testing.T) {
 	})
 }
 
+func TestCommandLineEnvironmentRunContext(t *testing.T) {
+	consoletest.WithMocks(func(input *consoletest.MockInput) {
+		input.PutString("print 1\nprint")
+
+		cle, _, sb := prepareTestCLE()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			cancel()
+		}()
+		assert.Equal(t, context.Canceled, cle.RunContext(ctx))
+		assert.Equal(t, ">1<|", sb.String())
+		input.AssertBufferConsumed(t)
+	})
+}
+
+func TestCommandLineEnvironmentConsole(t *testing.T) {
+	c, input, output := consoletest.NewMockConsole()
+	input.PutString("print 1\nfoo\nexit\n")
+
+	cle, _, sb := prepareTestCLE()
+	cle.Console = c
+	cle.SetStaticPrompt("test")
+	assert.NoError(t, cle.Run())
+	assert.Equal(t, ">1<|", sb.String())
+	assert.Equal(t, "test> print 1\ntest> foo\nUnknown command \"foo\"\ntest> exit\n", output.String())
+	input.AssertBufferConsumed(t)
+}
+
 func prepareTestCLE() (*Environment, *int, *strings.Builder) {
 	var sb strings.Builder
 	var lastCompletionIndex int
@@ -204,3 +458,116 @@ func newPrintHandler(sb *strings.Builder) func(args []string) error {
 		return nil
 	}
 }
+
+func TestCommandLineEnvironmentSetHistory(t *testing.T) {
+	consoletest.WithMocks(func(input *consoletest.MockInput) {
+		history := NewCommandHistory(10)
+		history.Put([]string{"print", "from history"})
+
+		cle, _, sb := prepareTestCLE()
+		cle.SetHistory(history)
+		input.PutKeys(console.KeyUp, console.KeyEnter)
+		input.PutString("exit\n")
+		assert.NoError(t, cle.Run())
+		assert.Equal(t, ">from history<|", sb.String())
+		requireHistEntry(t, history, 0, []string{"exit"})
+		input.AssertBufferConsumed(t)
+	})
+}
+
+func TestOptionsPrinterDescriptions(t *testing.T) {
+	c, _, output := consoletest.NewMockConsole()
+	output.Width = 30
+	NewOptionsPrinter(c)([]CompletionOption{
+		NewDescribedCompletionOption("deploy", "roll out the current build", false),
+		NewCompletionOption("status", false),
+		NewLabelledCompletionOption("RB", "rollback", false),
+	})
+	// descriptions are aligned and shortened to the terminal width
+	assert.Equal(t, "deploy  roll out the current\nstatus\nRB\n", output.String())
+
+	c, _, output = consoletest.NewMockConsole()
+	NewOptionsPrinter(c)(PrepareCompletionOptions([]string{"foo", "bar"}, false))
+	assert.Equal(t, "foo  bar\n", output.String())
+}
+
+func TestCommandLineEnvironmentCommandDescriptions(t *testing.T) {
+	cle := NewEnvironment()
+	cle.RegisterCommand(NewDescribedCommand("deploy", "roll out the current build", nil, nil))
+	cle.RegisterCommand(NewExitCommand("exit"))
+
+	options := filterOptions(cle.GetCompletionOptions([]string{""}, 0), "", nil)
+	if assert.Len(t, options, 2) {
+		assert.Equal(t, "roll out the current build", optionDescription(options[0]))
+		assert.Equal(t, "", optionDescription(options[1]))
+	}
+}
+
+func TestMergeCompletionOptions(t *testing.T) {
+	merged := MergeCompletionOptions(
+		NewCompletionGroup("files", PrepareCompletionOptions([]string{"b.txt"}, false)),
+		PrepareCompletionOptions([]string{"x"}, false),
+		append(NewCompletionGroup("flags", PrepareCompletionOptions([]string{"-v"}, false)),
+			NewCompletionGroup("files", PrepareCompletionOptions([]string{"a.txt"}, false))...),
+	)
+	assert.Equal(t, []string{"b.txt", "a.txt", "x", "-v"}, optionStrings(merged))
+	assert.Equal(t, "files", optionGroup(merged[1]))
+	assert.Equal(t, "", optionGroup(merged[2]))
+
+	// groups keep their order and are sorted separately
+	filtered := filterOptions(merged, "", nil)
+	assert.Equal(t, []string{"a.txt", "b.txt", "x", "-v"}, optionStrings(filtered))
+}
+
+func TestOptionsPrinterGroups(t *testing.T) {
+	c, _, output := consoletest.NewMockConsole()
+	options := append(NewCompletionGroup("remote", PrepareCompletionOptions([]string{"s3://a", "s3://b"}, false)),
+		NewCompletionGroup("flags", []CompletionOption{NewDescribedCompletionOption("-r", "recursive", false)})...)
+	NewOptionsPrinter(c)(options)
+	assert.Equal(t, "remote:\ns3://a  s3://b\nflags:\n-r  recursive\n", output.String())
+}
+
+func TestCommandLineEnvironmentCompletionSources(t *testing.T) {
+	cle := NewEnvironment()
+	cle.RegisterCommand(NewCustomCommand("cp", func(cmd []string, index int) []CompletionOption {
+		return NewCompletionGroup("files", PrepareCompletionOptions([]string{"local.txt"}, false))
+	}, nil))
+	cle.CompletionSources = []CommandCompletionHandler{
+		func(cmd []string, index int) []CompletionOption {
+			if index == 0 {
+				return nil
+			}
+			return append(NewCompletionGroup("remote", PrepareCompletionOptions([]string{"s3://bucket"}, false)),
+				NewCompletionGroup("files", PrepareCompletionOptions([]string{"cached.txt"}, false))...)
+		},
+	}
+
+	options := cle.GetCompletionOptions([]string{"cp", ""}, 1)
+	assert.Equal(t, []string{"local.txt", "cached.txt", "s3://bucket"}, optionStrings(options))
+	assert.Equal(t, []string{"cp"}, optionStrings(cle.GetCompletionOptions([]string{""}, 0)))
+}
+
+func TestReadKeyEventResize(t *testing.T) {
+	c, input, _ := consoletest.NewMockConsole()
+	resized := make(chan os.Signal, 1)
+	resizes := 0
+
+	// resize interrupts waiting for input without returning
+	resized <- os.Interrupt
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := readKeyEvent(ctx, c, resized, func() { resizes++ })
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, 1, resizes)
+
+	input.PutString("x")
+	var event console.KeyEvent
+	err = c.WithReadKeyContext(func() error {
+		event, err = readKeyEvent(context.Background(), c, resized, func() { resizes++ })
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 'x', event.Rune)
+	assert.Equal(t, 1, resizes)
+	input.AssertBufferConsumed(t)
+}
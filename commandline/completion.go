@@ -0,0 +1,232 @@
+package commandline
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sbreitf1/go-console"
+)
+
+const (
+	// completionIndicatorDelay denotes the time to wait for completion options before the progress indicator is displayed.
+	completionIndicatorDelay = 100 * time.Millisecond
+	// completionSpinnerInterval denotes the time between two frames of the progress indicator.
+	completionSpinnerInterval = 100 * time.Millisecond
+)
+
+var (
+	// completionSpinnerFrames are displayed after the line while waiting for completion options on consoles with color support.
+	completionSpinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+)
+
+// CommandCompletionContextHandler describes a completion handler like CommandCompletionHandler that should stop as soon as ctx is done.
+type CommandCompletionContextHandler func(ctx context.Context, currentCommand []string, entryIndex int) (options []CompletionOption)
+
+// ContextArgCompletion is implemented by argument completions that query slow sources and can be cancelled.
+type ContextArgCompletion interface {
+	ArgCompletion
+	// GetCompletionOptionsContext returns the options like GetCompletionOptions and should stop as soon as ctx is done.
+	GetCompletionOptionsContext(ctx context.Context, currentCommand []string, entryIndex int) (options []CompletionOption)
+}
+
+// ContextCompletionCommand is implemented by commands with a cancellable completion handler.
+type ContextCompletionCommand interface {
+	Command
+	// GetCompletionOptionsContext returns the options like GetCompletionOptions and should stop as soon as ctx is done.
+	GetCompletionOptionsContext(ctx context.Context, currentCommand []string, entryIndex int) []CompletionOption
+}
+
+// NewFixedArgCompletionContext returns a cancellable completion handler like NewFixedArgCompletion that passes ctx to ContextArgCompletion arguments.
+func NewFixedArgCompletionContext(args ...ArgCompletion) CommandCompletionContextHandler {
+	return func(ctx context.Context, currentCommand []string, entryIndex int) (options []CompletionOption) {
+		if entryIndex > 0 && entryIndex <= len(args) {
+			return getArgCompletionOptions(ctx, args[entryIndex-1], currentCommand, entryIndex)
+		}
+		return nil
+	}
+}
+
+// getArgCompletionOptions returns the options of arg and passes ctx if supported.
+func getArgCompletionOptions(ctx context.Context, arg ArgCompletion, currentCommand []string, entryIndex int) []CompletionOption {
+	if contextArg, ok := arg.(ContextArgCompletion); ok {
+		return contextArg.GetCompletionOptionsContext(ctx, currentCommand, entryIndex)
+	}
+	return arg.GetCompletionOptions(currentCommand, entryIndex)
+}
+
+type contextCommand struct {
+	*customCommand
+	contextCompletionHandler CommandCompletionContextHandler
+}
+
+// NewCustomCommandContext returns a named command like NewCustomCommand with a cancellable completion handler.
+func NewCustomCommandContext(name string, completionHandler CommandCompletionContextHandler, execHandler ExecCommandHandler) Command {
+	return &contextCommand{&customCommand{name, nil, execHandler, ""}, completionHandler}
+}
+
+func (c *contextCommand) GetCompletionOptions(currentCommand []string, entryIndex int) []CompletionOption {
+	return c.GetCompletionOptionsContext(context.Background(), currentCommand, entryIndex)
+}
+func (c *contextCommand) GetCompletionOptionsContext(ctx context.Context, currentCommand []string, entryIndex int) []CompletionOption {
+	if c.contextCompletionHandler != nil {
+		return c.contextCompletionHandler(ctx, currentCommand, entryIndex)
+	}
+	return nil
+}
+
+type cachedArgCompletion struct {
+	arg ArgCompletion
+	ttl time.Duration
+	now func() time.Time
+
+	mutex   sync.Mutex
+	entries map[string]cachedCompletionOptions
+}
+
+type cachedCompletionOptions struct {
+	options []CompletionOption
+	expires time.Time
+}
+
+// NewCachedArgCompletion returns an argument completion that keeps the options of arg for ttl and reuses them for longer prefixes.
+func NewCachedArgCompletion(arg ArgCompletion, ttl time.Duration) ContextArgCompletion {
+	return &cachedArgCompletion{arg: arg, ttl: ttl, now: time.Now, entries: make(map[string]cachedCompletionOptions)}
+}
+
+func (a *cachedArgCompletion) GetCompletionOptions(currentCommand []string, entryIndex int) []CompletionOption {
+	return a.GetCompletionOptionsContext(context.Background(), currentCommand, entryIndex)
+}
+
+func (a *cachedArgCompletion) GetCompletionOptionsContext(ctx context.Context, currentCommand []string, entryIndex int) []CompletionOption {
+	preceding := currentCommand[:min(entryIndex, len(currentCommand))]
+	prefix := ""
+	if entryIndex < len(currentCommand) {
+		prefix = currentCommand[entryIndex]
+	}
+	key := func(prefix string) string {
+		return strings.Join(append(preceding[:len(preceding):len(preceding)], prefix), "\x00")
+	}
+
+	for end := len(prefix); ; {
+		if options, ok := a.get(key(prefix[:end])); ok {
+			return options
+		}
+		if end == 0 {
+			break
+		}
+		_, size := utf8.DecodeLastRuneInString(prefix[:end])
+		end -= size
+	}
+
+	options := getArgCompletionOptions(ctx, a.arg, currentCommand, entryIndex)
+	if ctx.Err() == nil {
+		a.put(key(prefix), options)
+	}
+	return options
+}
+
+func (a *cachedArgCompletion) get(key string) ([]CompletionOption, bool) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	entry, ok := a.entries[key]
+	if !ok || !a.now().Before(entry.expires) {
+		return nil, false
+	}
+	return entry.options, true
+}
+
+func (a *cachedArgCompletion) put(key string, options []CompletionOption) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	now := a.now()
+	for k, entry := range a.entries {
+		// drop expired entries to not grow indefinitely
+		if !now.Before(entry.expires) {
+			delete(a.entries, k)
+		}
+	}
+	a.entries[key] = cachedCompletionOptions{options, now.Add(a.ttl)}
+}
+
+// awaitCompletion queries the completion options in the background until a key press cancels it. ok is true for keys to process.
+func awaitCompletion(ctx context.Context, line *lineEditor, handler CommandCompletionContextHandler, currentCommand []string, entryIndex int, timeout time.Duration) (options []CompletionOption, event console.KeyEvent, ok bool, err error) {
+	completionCtx, cancel := context.WithCancel(ctx)
+	if timeout > 0 {
+		completionCtx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	defer cancel()
+
+	result := make(chan []CompletionOption, 1)
+	go func() {
+		result <- handler(completionCtx, currentCommand, entryIndex)
+	}()
+
+	// key presses cancel the completion
+	readCtx, stopReading := context.WithCancel(ctx)
+	type keyResult struct {
+		event console.KeyEvent
+		err   error
+	}
+	keys := make(chan keyResult, 1)
+	go func() {
+		event, err := line.console.ReadKeyEventContext(readCtx)
+		keys <- keyResult{event, err}
+	}()
+	// waitForKey stops reading and returns a key event that has been read in the meantime
+	waitForKey := func() (console.KeyEvent, bool) {
+		stopReading()
+		key := <-keys
+		return key.event, key.err == nil
+	}
+
+	frames := completionSpinnerFrames
+	if line.console.ColorDepth() == console.ColorDepthNone {
+		frames = []string{"…"}
+	}
+	// indicator is only displayed for slow completions
+	indicator := time.NewTimer(completionIndicatorDelay)
+	defer indicator.Stop()
+	indicated := false
+	defer func() {
+		if indicated {
+			line.SetHint("")
+		}
+	}()
+
+	for frame := 0; ; frame++ {
+		select {
+		case options := <-result:
+			if event, ok := waitForKey(); ok {
+				// user has continued typing
+				return nil, event, event.Key != console.KeyEscape, nil
+			}
+			return options, console.KeyEvent{}, false, nil
+
+		case key := <-keys:
+			stopReading()
+			if key.err != nil {
+				return nil, console.KeyEvent{}, false, key.err
+			}
+			return nil, key.event, key.event.Key != console.KeyEscape, nil
+
+		case <-completionCtx.Done():
+			event, ok := waitForKey()
+			if err := ctx.Err(); err != nil {
+				return nil, console.KeyEvent{}, false, err
+			}
+			// completion has timed out, but a key might have been pressed in the meantime
+			return nil, event, ok && event.Key != console.KeyEscape, nil
+
+		case <-indicator.C:
+			line.SetHint(" " + frames[frame%len(frames)])
+			line.Refresh()
+			indicated = true
+			indicator.Reset(completionSpinnerInterval)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package commandline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sbreitf1/go-console"
+	"github.com/sbreitf1/go-console/consoletest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadCommandCompletionContext(t *testing.T) {
+	c, input, output := consoletest.NewMockConsole()
+	cancelled := make(chan error, 1)
+	opts := &ReadCommandOptions{
+		Console: c,
+		GetCompletionOptionsContext: func(ctx context.Context, currentCommand []string, entryIndex int) []CompletionOption {
+			if currentCommand[0] == "f" {
+				return PrepareCompletionOptions([]string{"foo"}, false)
+			}
+			// slow completion only returns after cancellation
+			<-ctx.Done()
+			cancelled <- ctx.Err()
+			return PrepareCompletionOptions([]string{"print"}, false)
+		},
+	}
+
+	// previous tests must not trigger double-tab
+	lastTabPress = time.Unix(0, 0)
+	// keys that are typed before the options are available cancel the completion, so only wait for input afterwards
+	ctx, cancel := context.WithTimeout(context.Background(), completionIndicatorDelay)
+	defer cancel()
+	input.PutString("f\t")
+	_, err := ReadCommandContext(ctx, "", opts)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Contains(t, output.String(), "> foo")
+
+	// typing cancels the completion and is processed as usual
+	input.PutString("pr\tx\n")
+	cmd, err := ReadCommand("", opts)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"prx"}, cmd)
+	assert.Equal(t, context.Canceled, <-cancelled)
+
+	// Esc only cancels the completion
+	input.PutString("pr\t")
+	input.PutKeys(console.KeyEscape)
+	input.PutString("\n")
+	cmd, err = ReadCommand("", opts)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"pr"}, cmd)
+	assert.Equal(t, context.Canceled, <-cancelled)
+	input.AssertBufferConsumed(t)
+}
+
+func TestReadCommandCompletionTimeout(t *testing.T) {
+	c, input, output := consoletest.NewMockConsole()
+	cancelled := make(chan error, 1)
+	opts := &ReadCommandOptions{
+		Console: c,
+		GetCompletionOptionsContext: func(ctx context.Context, currentCommand []string, entryIndex int) []CompletionOption {
+			<-ctx.Done()
+			cancelled <- ctx.Err()
+			return nil
+		},
+		CompletionTimeout: 2 * completionIndicatorDelay,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 4*completionIndicatorDelay)
+	defer cancel()
+	input.PutString("pr\t")
+	_, err := ReadCommandContext(ctx, "", opts)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, context.DeadlineExceeded, <-cancelled)
+	// indicator is displayed while waiting and removed afterwards
+	assert.Contains(t, output.String(), "> pr …\b\b  \b\b")
+	input.AssertBufferConsumed(t)
+}
+
+type testContextKey struct{}
+
+func TestEnvironmentCompletionContext(t *testing.T) {
+	cle := NewEnvironment()
+	var receivedCtx context.Context
+	cle.RegisterCommand(NewCustomCommandContext("cmd", func(ctx context.Context, currentCommand []string, entryIndex int) []CompletionOption {
+		receivedCtx = ctx
+		return PrepareCompletionOptions([]string{"foo"}, false)
+	}, nil))
+
+	ctx := context.WithValue(context.Background(), testContextKey{}, "value")
+	assert.Equal(t, []string{"foo"}, optionStrings(cle.GetCompletionOptionsContext(ctx, []string{"cmd", ""}, 1)))
+	assert.Equal(t, "value", receivedCtx.Value(testContextKey{}))
+	assert.Equal(t, []string{"foo"}, optionStrings(cle.GetCompletionOptions([]string{"cmd", ""}, 1)))
+}
+
+type countingArgCompletion struct {
+	calls int
+}
+
+func (a *countingArgCompletion) GetCompletionOptions(currentCommand []string, entryIndex int) []CompletionOption {
+	a.calls++
+	return PrepareCompletionOptions([]string{currentCommand[entryIndex] + "x"}, false)
+}
+
+func TestCachedArgCompletion(t *testing.T) {
+	arg := &countingArgCompletion{}
+	cached := NewCachedArgCompletion(arg, time.Minute).(*cachedArgCompletion)
+	now := time.Unix(1000, 0)
+	cached.now = func() time.Time { return now }
+
+	assert.Equal(t, []string{"ax"}, optionStrings(cached.GetCompletionOptions([]string{"cmd", "a"}, 1)))
+	assert.Equal(t, []string{"ax"}, optionStrings(cached.GetCompletionOptions([]string{"cmd", "a", "b"}, 1)))
+	assert.Equal(t, 1, arg.calls)
+
+	// longer prefixes reuse the options and other prefixes are queried separately
+	assert.Equal(t, []string{"ax"}, optionStrings(cached.GetCompletionOptions([]string{"cmd", "ab"}, 1)))
+	assert.Equal(t, 1, arg.calls)
+	assert.Equal(t, []string{"bx"}, optionStrings(cached.GetCompletionOptions([]string{"cmd", "b"}, 1)))
+	assert.Equal(t, 2, arg.calls)
+	assert.Equal(t, []string{"x"}, optionStrings(cached.GetCompletionOptions([]string{"other", ""}, 1)))
+	assert.Equal(t, 3, arg.calls)
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, []string{"ax"}, optionStrings(cached.GetCompletionOptions([]string{"cmd", "a"}, 1)))
+	assert.Equal(t, 4, arg.calls)
+	// expired entries are removed
+	assert.Len(t, cached.entries, 1)
+
+	// cancelled queries are not cached
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cached.GetCompletionOptionsContext(ctx, []string{"cmd", "c"}, 1)
+	cached.GetCompletionOptions([]string{"cmd", "c"}, 1)
+	assert.Equal(t, 6, arg.calls)
+}
+
+func TestEnvironmentCompletionWithoutContext(t *testing.T) {
+	c, input, _ := consoletest.NewMockConsole()
+	cle := NewEnvironment()
+	cle.Console = c
+	cle.RegisterCommand(NewExitCommand("exit"))
+	var args []string
+	cle.RegisterCommand(NewCustomCommand("slow", func(currentCommand []string, entryIndex int) []CompletionOption {
+		time.Sleep(2 * completionIndicatorDelay)
+		return PrepareCompletionOptions([]string{"foo"}, false)
+	}, func(a []string) error {
+		args = a
+		return nil
+	}))
+
+	// handlers without context are not cancelled by typing
+	lastTabPress = time.Unix(0, 0)
+	input.PutString("slow f\tx\n")
+	input.PutString("exit\n")
+	assert.NoError(t, cle.Run())
+	assert.Equal(t, []string{"foo", "x"}, args)
+	input.AssertBufferConsumed(t)
+}
+
+func TestEnvironmentResolveCompletion(t *testing.T) {
+	cle := NewEnvironment()
+	cle.RegisterCommand(NewCustomCommand("sync", func(currentCommand []string, entryIndex int) []CompletionOption {
+		return PrepareCompletionOptions([]string{"foo"}, false)
+	}, nil))
+	cle.RegisterCommand(NewCustomCommandContext("async", func(ctx context.Context, currentCommand []string, entryIndex int) []CompletionOption {
+		return PrepareCompletionOptions([]string{"bar"}, false)
+	}, nil))
+	cle.CompletionSources = []CommandCompletionHandler{
+		func(currentCommand []string, entryIndex int) []CompletionOption {
+			return PrepareCompletionOptions([]string{"source"}, false)
+		},
+	}
+
+	options, background := cle.resolveCompletion([]string{"sync", ""}, 1)
+	assert.Nil(t, background)
+	assert.Equal(t, []string{"foo", "source"}, optionStrings(options))
+
+	// sources are evaluated right away, only the command is called in the background
+	options, background = cle.resolveCompletion([]string{"async", ""}, 1)
+	assert.Equal(t, []string{"source"}, optionStrings(options))
+	if assert.NotNil(t, background) {
+		assert.Equal(t, []string{"bar"}, optionStrings(background(context.Background(), []string{"async", ""}, 1)))
+	}
+}
@@ -91,3 +91,27 @@ func IsErrCommandPanicked(err error) bool {
 	_, ok := err.(errCommandPanicked)
 	return ok
 }
+
+/* ################################################ */
+/* ###            history expansion             ### */
+/* ################################################ */
+
+type errHistoryExpansion struct {
+	event  string
+	reason string
+}
+
+func (e errHistoryExpansion) Error() string {
+	return fmt.Sprintf("%s: %s", e.event, e.reason)
+}
+
+// ErrHistoryExpansion returns a new error that indicates a history reference that could not be expanded.
+func ErrHistoryExpansion(event, reason string) error {
+	return errHistoryExpansion{event, reason}
+}
+
+// IsErrHistoryExpansion returns true when the error indicates a history reference that could not be expanded.
+func IsErrHistoryExpansion(err error) bool {
+	_, ok := err.(errHistoryExpansion)
+	return ok
+}
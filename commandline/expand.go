@@ -0,0 +1,158 @@
+package commandline
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ExpandHistory replaces history references like !!, !-n, !n, !prefix, !$ and ^old^new like bash does. getHistoryEvent can be nil.
+func ExpandHistory(str string, getHistoryEntry CommandHistoryHandler, getHistoryEvent CommandHistoryEventHandler) (expanded string, changed bool, err error) {
+	if strings.HasPrefix(str, "^") {
+		return expandSubstitution(str, getHistoryEntry)
+	}
+
+	runes := []rune(str)
+	var sb strings.Builder
+	singleQuote, doubleQuote := false, false
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case singleQuote:
+			singleQuote = r != '\''
+		case r == '\\' && i+1 < len(runes):
+			// keep escape sequence as it is
+			sb.WriteRune(r)
+			i++
+			r = runes[i]
+		case r == '"':
+			doubleQuote = !doubleQuote
+		case r == '\'' && !doubleQuote:
+			singleQuote = true
+		case r == '!' && i+1 < len(runes):
+			if event, n := historyEvent(runes[i+1:]); n > 0 {
+				replacement, err := resolveHistoryEvent(event, getHistoryEntry, getHistoryEvent)
+				if err != nil {
+					return "", false, err
+				}
+				sb.WriteString(replacement)
+				changed = true
+				i += n
+				continue
+			}
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String(), changed, nil
+}
+
+// historyEvent returns the history reference following an exclamation mark and its length in runes, or 0 if there is none.
+func historyEvent(runes []rune) (string, int) {
+	switch r := runes[0]; {
+	case r == '!', r == '$':
+		return string(r), 1
+
+	case r == '-', unicode.IsDigit(r):
+		n := 1
+		for n < len(runes) && unicode.IsDigit(runes[n]) {
+			n++
+		}
+		if r == '-' && n == 1 {
+			return "", 0
+		}
+		return string(runes[:n]), n
+
+	case unicode.IsSpace(r), r == '=', r == '(', r == '"', r == '\'':
+		return "", 0
+	}
+
+	// prefix ends at whitespace, quotes or shell operators
+	n := 0
+	for n < len(runes) && !unicode.IsSpace(runes[n]) && !strings.ContainsRune("\"';&|()<>", runes[n]) {
+		n++
+	}
+	return string(runes[:n]), n
+}
+
+func resolveHistoryEvent(event string, getHistoryEntry CommandHistoryHandler, getHistoryEvent CommandHistoryEventHandler) (string, error) {
+	notFound := ErrHistoryExpansion("!"+event, "event not found")
+	if getHistoryEntry == nil {
+		return "", notFound
+	}
+
+	switch {
+	case event == "!":
+		if cmd, ok := getHistoryEntry(0); ok {
+			return GetCommandString(cmd), nil
+		}
+
+	case event == "$":
+		if cmd, ok := getHistoryEntry(0); ok && len(cmd) > 0 {
+			return Quote(cmd[len(cmd)-1]), nil
+		}
+
+	case strings.HasPrefix(event, "-"):
+		if n, err := strconv.Atoi(event[1:]); err == nil && n > 0 {
+			if cmd, ok := getHistoryEntry(n - 1); ok {
+				return GetCommandString(cmd), nil
+			}
+		}
+
+	case unicode.IsDigit(rune(event[0])):
+		if n, err := strconv.Atoi(event); err == nil && n > 0 {
+			if getHistoryEvent != nil {
+				if cmd, ok := getHistoryEvent(n); ok {
+					return GetCommandString(cmd), nil
+				}
+			} else if index := historyLen(getHistoryEntry) - n; index >= 0 {
+				if cmd, ok := getHistoryEntry(index); ok {
+					return GetCommandString(cmd), nil
+				}
+			}
+		}
+
+	default:
+		for i := 0; ; i++ {
+			cmd, ok := getHistoryEntry(i)
+			if !ok {
+				break
+			}
+			if str := GetCommandString(cmd); strings.HasPrefix(str, event) {
+				return str, nil
+			}
+		}
+	}
+	return "", notFound
+}
+
+// expandSubstitution handles the quick substitution ^old^new^ on the previous command.
+func expandSubstitution(str string, getHistoryEntry CommandHistoryHandler) (string, bool, error) {
+	parts := strings.SplitN(str[1:], "^", 3)
+	if len(parts) < 2 || len(parts[0]) == 0 {
+		return str, false, nil
+	}
+	old, replacement, suffix := parts[0], parts[1], ""
+	if len(parts) == 3 {
+		suffix = parts[2]
+	}
+
+	if getHistoryEntry != nil {
+		if cmd, ok := getHistoryEntry(0); ok {
+			if prev := GetCommandString(cmd); strings.Contains(prev, old) {
+				return strings.Replace(prev, old, replacement, 1) + suffix, true, nil
+			}
+		}
+	}
+	return "", false, ErrHistoryExpansion("^"+old+"^"+replacement, "substitution failed")
+}
+
+// historyLen returns the number of entries in history.
+func historyLen(getHistoryEntry CommandHistoryHandler) int {
+	n := 0
+	for {
+		if _, ok := getHistoryEntry(n); !ok {
+			return n
+		}
+		n++
+	}
+}
@@ -0,0 +1,97 @@
+package commandline
+
+import (
+	"testing"
+
+	"github.com/sbreitf1/go-console/consoletest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandHistory(t *testing.T) {
+	// latest entry first
+	history := newTestHistory(`git commit -m "fix typo"`, "deploy prod", "ls -la /tmp", "echo 1")
+
+	tests := []struct {
+		input    string
+		expected string
+		changed  bool
+	}{
+		{"ls", "ls", false},
+		{"!!", `git commit -m "fix typo"`, true},
+		{"sudo !!", `sudo git commit -m "fix typo"`, true},
+		{"!-2", "deploy prod", true},
+		{"!1", "echo 1", true},
+		{"!3", "deploy prod", true},
+		{"!dep --force", "deploy prod --force", true},
+		{"cat !$", `cat "fix typo"`, true},
+		{"!l; !e", "ls -la /tmp; echo 1", true},
+		{"echo hello!", "echo hello!", false},
+		{"echo ! != x", "echo ! != x", false},
+		{`echo '!!' \!!`, `echo '!!' \!!`, false},
+		{`echo "!$"`, `echo ""fix typo""`, true},
+		{"^git^hub^ --amend", `hub commit -m "fix typo" --amend`, true},
+		{"^typo^bug", `git commit -m "fix bug"`, true},
+	}
+	for _, test := range tests {
+		expanded, changed, err := ExpandHistory(test.input, history, nil)
+		assert.NoError(t, err, test.input)
+		assert.Equal(t, test.expected, expanded, test.input)
+		assert.Equal(t, test.changed, changed, test.input)
+	}
+}
+
+func TestExpandHistoryErrors(t *testing.T) {
+	history := newTestHistory("deploy prod")
+
+	for _, input := range []string{"!foo", "!-2", "!2", "!0", "^staging^prod"} {
+		_, _, err := ExpandHistory(input, history, nil)
+		assert.True(t, IsErrHistoryExpansion(err), input)
+	}
+	_, _, err := ExpandHistory("!!", nil, nil)
+	assert.True(t, IsErrHistoryExpansion(err))
+	assert.Equal(t, "!!: event not found", err.Error())
+}
+
+func TestExpandHistoryEvents(t *testing.T) {
+	history := NewCommandHistory(10).(MetadataCommandHistory)
+	history.Put([]string{"deploy", "prod"})
+	history.Put([]string{"status"})
+	history.Put([]string{"deploy", "prod"})
+	getHistoryEvent := func(number int) ([]string, bool) {
+		if index, ok := history.FindEvent(number); ok {
+			return history.GetHistoryEntry(index)
+		}
+		return nil, false
+	}
+
+	// numbers are kept when the duplicate is erased
+	expanded, _, err := ExpandHistory("!2", history.GetHistoryEntry, getHistoryEvent)
+	assert.NoError(t, err)
+	assert.Equal(t, "status", expanded)
+	_, _, err = ExpandHistory("!1", history.GetHistoryEntry, getHistoryEvent)
+	assert.True(t, IsErrHistoryExpansion(err))
+}
+
+func TestCommandLineEnvironmentHistoryExpansion(t *testing.T) {
+	c, input, output := consoletest.NewMockConsole()
+	input.PutString("print foo bar\n")
+	input.PutString("print !$\n")
+	input.PutString("!nope\n")
+	input.PutString("^bar^baz\n")
+	input.PutString("print '!!'\n")
+	input.PutString("exit\n")
+
+	cle, _, sb := prepareTestCLE()
+	cle.Console = c
+	cle.SetStaticPrompt("")
+	cle.HistoryExpansion = true
+	assert.NoError(t, cle.Run())
+	assert.Equal(t, ">foo<>bar<|>bar<|>baz<|>!!<|", sb.String())
+	assert.Equal(t, "> print foo bar\n> print !$\nprint bar\n> !nope\n!nope: event not found\n> ^bar^baz\nprint baz\n> print '!!'\n> exit\n", output.String())
+	input.AssertBufferConsumed(t)
+
+	cmd, ok := cle.history.GetHistoryEntry(2)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"print", "baz"}, cmd)
+}
@@ -0,0 +1,170 @@
+package commandline
+
+import (
+	"strings"
+
+	"github.com/sbreitf1/go-console"
+)
+
+var (
+	// commandStyle is used to highlight the name of a known command.
+	commandStyle = console.Style{Foreground: console.ColorGreen}
+	// unknownCommandStyle is used to highlight the name of an unknown command.
+	unknownCommandStyle = console.Style{Foreground: console.ColorRed}
+	// quoteStyle is used to highlight quoted phrases.
+	quoteStyle = console.Style{Foreground: console.ColorYellow}
+	// flagStyle is used to highlight arguments starting with a dash.
+	flagStyle = console.Style{Foreground: console.ColorCyan}
+)
+
+// StyledSpan denotes a part of the command line that is displayed with a style. Start and End are byte offsets in the command line.
+type StyledSpan struct {
+	Start int
+	End   int
+	Style console.Style
+}
+
+// Highlighter returns the styled spans of the typed command line including previous lines. Later spans take precedence.
+type Highlighter func(line string) []StyledSpan
+
+// NewCommandHighlighter returns a Highlighter that colorizes known and unknown command names, quoted phrases and flags.
+func NewCommandHighlighter(isCommand func(name string) bool) Highlighter {
+	return func(line string) []StyledSpan {
+		tokens, _ := scanCommand(line)
+		spans := make([]StyledSpan, 0, len(tokens))
+		for i, token := range tokens {
+			if i == 0 {
+				style := unknownCommandStyle
+				if isCommand != nil && isCommand(token.value) {
+					style = commandStyle
+				}
+				spans = append(spans, StyledSpan{token.start, token.end, style})
+			} else if line[token.start] == '-' {
+				spans = append(spans, StyledSpan{token.start, token.end, flagStyle})
+			}
+			for _, quote := range token.quotes {
+				spans = append(spans, StyledSpan{quote[0], quote[1], quoteStyle})
+			}
+		}
+		return spans
+	}
+}
+
+// commandToken denotes a single part of a command line.
+type commandToken struct {
+	// start and end denote the byte offsets of the token in the command line including quotes and escape characters.
+	start, end int
+	// value denotes the token with resolved quotes and escape sequences.
+	value string
+	// quotes holds the byte offsets of all quoted phrases in the token including the quote characters. An unclosed phrase ends with the token.
+	quotes [][2]int
+}
+
+// scanCommand splits a command line into tokens like ParseCommand, but keeps their positions.
+func scanCommand(str string) (tokens []commandToken, isComplete bool) {
+	tokens = make([]commandToken, 0)
+
+	var sb strings.Builder
+	var token commandToken
+	inToken := false
+	escape := false
+	doubleQuote := false
+	singleQuote := false
+	quoteStart := 0
+
+	for i, r := range str {
+		if !inToken && r != ' ' {
+			token = commandToken{start: i}
+			inToken = true
+		}
+
+		if singleQuote {
+			if r == '\'' {
+				singleQuote = false
+				token.quotes = append(token.quotes, [2]int{quoteStart, i + 1})
+			} else {
+				sb.WriteRune(r)
+			}
+
+		} else if doubleQuote {
+			if escape {
+				if r != '\\' && r != '$' && r != '"' {
+					// consume escape character only for actual escape sequences
+					sb.WriteRune('\\')
+				}
+				sb.WriteRune(r)
+				escape = false
+
+			} else {
+				if r == '"' {
+					doubleQuote = false
+					token.quotes = append(token.quotes, [2]int{quoteStart, i + 1})
+				} else if r == '\\' {
+					escape = true
+				} else {
+					sb.WriteRune(r)
+				}
+			}
+		} else if escape {
+			sb.WriteRune(r)
+			escape = false
+
+		} else {
+			if r == '\\' {
+				escape = true
+			} else if r == '\'' {
+				singleQuote = true
+				quoteStart = i
+			} else if r == '"' {
+				doubleQuote = true
+				quoteStart = i
+			} else if r == ' ' {
+				if inToken {
+					token.end = i
+					token.value = sb.String()
+					tokens = append(tokens, token)
+					sb.Reset()
+					inToken = false
+				}
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+
+	if inToken {
+		if singleQuote || doubleQuote {
+			token.quotes = append(token.quotes, [2]int{quoteStart, len(str)})
+		}
+		token.end = len(str)
+		token.value = sb.String()
+		tokens = append(tokens, token)
+	}
+
+	return tokens, (!escape && !singleQuote && !doubleQuote)
+}
+
+// runeStyles returns the style of every rune in line according to spans that are shifted by offset bytes.
+func runeStyles(line []rune, spans []StyledSpan, offset int) []console.Style {
+	styles := make([]console.Style, len(line))
+	if len(spans) == 0 {
+		return styles
+	}
+
+	// byte offset of every rune
+	positions := make([]int, len(line))
+	pos := offset
+	for i, r := range line {
+		positions[i] = pos
+		pos += len(string(r))
+	}
+
+	for _, span := range spans {
+		for i := range line {
+			if positions[i] >= span.Start && positions[i] < span.End {
+				styles[i] = span.Style
+			}
+		}
+	}
+	return styles
+}
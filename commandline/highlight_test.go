@@ -0,0 +1,90 @@
+package commandline
+
+import (
+	"testing"
+
+	"github.com/sbreitf1/go-console"
+	"github.com/sbreitf1/go-console/consoletest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanCommand(t *testing.T) {
+	tokens, isComplete := scanCommand(`echo  "foo bar"x -n '' a\ b`)
+	assert.True(t, isComplete)
+	assert.Equal(t, []commandToken{
+		{start: 0, end: 4, value: "echo"},
+		{start: 6, end: 16, value: "foo barx", quotes: [][2]int{{6, 15}}},
+		{start: 17, end: 19, value: "-n"},
+		{start: 20, end: 22, value: "", quotes: [][2]int{{20, 22}}},
+		{start: 23, end: 27, value: "a b"},
+	}, tokens)
+
+	tokens, isComplete = scanCommand(`print 'foo `)
+	assert.False(t, isComplete)
+	assert.Equal(t, []commandToken{
+		{start: 0, end: 5, value: "print"},
+		{start: 6, end: 11, value: "foo ", quotes: [][2]int{{6, 11}}},
+	}, tokens)
+}
+
+func TestCommandHighlighter(t *testing.T) {
+	highlighter := NewCommandHighlighter(func(name string) bool { return name == "print" })
+
+	assert.Equal(t, []StyledSpan{
+		{0, 5, commandStyle},
+		{6, 8, flagStyle},
+		{9, 14, quoteStyle},
+	}, highlighter(`print -n "foo" bar`))
+	assert.Equal(t, []StyledSpan{
+		{0, 4, unknownCommandStyle},
+		{5, 12, flagStyle},
+		{7, 12, quoteStyle},
+	}, highlighter(`prin --"foo `))
+	assert.Empty(t, highlighter("  "))
+}
+
+func TestRuneStyles(t *testing.T) {
+	styles := runeStyles([]rune("äb c"), []StyledSpan{{0, 3, commandStyle}, {2, 3, flagStyle}}, 0)
+	assert.Equal(t, []console.Style{commandStyle, flagStyle, {}, {}}, styles)
+
+	// spans refer to the whole command including previous lines
+	styles = runeStyles([]rune("x'"), []StyledSpan{{0, 2, commandStyle}, {4, 10, quoteStyle}}, 3)
+	assert.Equal(t, []console.Style{{}, quoteStyle}, styles)
+}
+
+func TestCommandLineEnvironmentHighlighting(t *testing.T) {
+	c, input, output := consoletest.NewMockConsole()
+	output.Colors = true
+
+	cle, _, sb := prepareTestCLE()
+	cle.Console = c
+	cle.SetStaticPrompt("")
+	cle.Suggester = nil
+
+	input.PutString("print -x 'a'\n")
+	input.PutString("exit\n")
+	assert.NoError(t, cle.Run())
+	assert.Equal(t, ">-x<>a<|", sb.String())
+	input.AssertBufferConsumed(t)
+
+	// command name is redrawn in green as soon as it is complete
+	assert.Contains(t, output.String(), "> \x1b[31mp\x1b[0m\x1b[31mr\x1b[0m\x1b[31mi\x1b[0m\x1b[31mn\x1b[0m\b\b\b\b\x1b[32mprint\x1b[0m")
+	assert.Contains(t, output.String(), " \x1b[36m-\x1b[0m\x1b[36mx\x1b[0m \x1b[33m'\x1b[0m\x1b[33ma\x1b[0m\x1b[33m'\x1b[0m\n")
+}
+
+func TestReadCommandHighlighterMultipleLines(t *testing.T) {
+	c, input, output := consoletest.NewMockConsole()
+	output.Colors = true
+	var lines []string
+	highlighter := func(line string) []StyledSpan {
+		lines = append(lines, line)
+		return nil
+	}
+
+	input.PutString("print 'foo\nbar'\n")
+	cmd, err := ReadCommand("", &ReadCommandOptions{Console: c, Highlighter: highlighter})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"print", "foo\nbar"}, cmd)
+	assert.Contains(t, lines, "print 'foo\nbar'")
+}
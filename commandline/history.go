@@ -1,29 +1,91 @@
 package commandline
 
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
 // CommandHistory defines the interface to a history of commands.
 type CommandHistory interface {
 	Put([]string)
 	GetHistoryEntry(int) ([]string, bool)
+	// Search returns the latest entry at index start or older that contains query.
+	Search(query string, start int) (int, []string, bool)
+}
+
+// HistoryEntry denotes a command in history with optional metadata about its execution.
+type HistoryEntry struct {
+	Command []string
+	// Input denotes the command line as typed. Optional, it is only used to filter and redact the entry.
+	Input string
+	// Time denotes the start of the execution. Zero if unknown.
+	Time time.Time
+	// Duration denotes how long the command has been running.
+	Duration time.Duration
+	// Errored is true when the command has returned an error.
+	Errored bool
+	// WorkingDir denotes the working directory at the start of the execution. Empty if unknown.
+	WorkingDir string
+	// Number denotes the event number for history expansion. It is assigned by the history and kept when older entries are removed.
+	Number int
+}
+
+// MetadataCommandHistory is implemented by command histories that keep metadata for their entries and can be edited.
+type MetadataCommandHistory interface {
+	CommandHistory
+	// PutEntry saves a new entry with metadata as latest entry.
+	PutEntry(HistoryEntry)
+	// GetEntry returns the entry at the given index. Index 0 denotes the latest entry.
+	GetEntry(int) (HistoryEntry, bool)
+	// Delete removes the entry at the given index.
+	Delete(int) bool
+	// Clear removes all entries.
+	Clear()
+	// FindEvent returns the index of the entry with the given event number.
+	FindEvent(number int) (int, bool)
 }
 
 type memoryCommandHistory struct {
 	maxCount int
-	history  [][]string
+	history  []HistoryEntry
+	opts     HistoryOptions
+	// count denotes the event number of the latest entry.
+	count int
 }
 
-// NewCommandHistory returns a new command history for maxCount entries.
+// NewCommandHistory returns a new command history for maxCount entries that erases duplicates and implements MetadataCommandHistory.
 func NewCommandHistory(maxCount int) CommandHistory {
-	return &memoryCommandHistory{maxCount, make([][]string, 0)}
+	return NewCommandHistoryWithOptions(maxCount, HistoryOptions{EraseDuplicates: true})
+}
+
+// NewCommandHistoryWithOptions returns a new command history like NewCommandHistory that only saves entries accepted by opts.
+func NewCommandHistoryWithOptions(maxCount int, opts HistoryOptions) CommandHistory {
+	return &memoryCommandHistory{maxCount, make([]HistoryEntry, 0), opts, 0}
 }
 
 // Put saves a new command to the history as latest entry.
 func (h *memoryCommandHistory) Put(cmd []string) {
-	if oldPos := h.find(cmd); oldPos >= 0 {
+	h.PutEntry(HistoryEntry{Command: cmd})
+}
+
+// PutEntry saves a new entry to the history as latest entry.
+func (h *memoryCommandHistory) PutEntry(entry HistoryEntry) {
+	if entry, ok := h.opts.applyCommand(entry); ok {
+		h.insert(entry)
+	}
+}
+
+// insert adds an already filtered entry as latest entry.
+func (h *memoryCommandHistory) insert(entry HistoryEntry) {
+	h.count++
+	entry.Number = h.count
+	if oldPos := h.find(entry.Command); oldPos >= 0 && h.opts.EraseDuplicates {
 		// remove old entry from list
 		h.history = append(h.history[:oldPos], h.history[oldPos+1:]...)
 	}
 
-	h.history = append([][]string{cmd}, h.history...)
+	h.history = append([]HistoryEntry{entry}, h.history...)
 	if len(h.history) > h.maxCount {
 		h.history = h.history[:h.maxCount]
 	}
@@ -32,9 +94,9 @@ func (h *memoryCommandHistory) Put(cmd []string) {
 func (h *memoryCommandHistory) find(cmd []string) int {
 HistLoop:
 	for i := range h.history {
-		if len(h.history[i]) == len(cmd) {
-			for j := range h.history[i] {
-				if cmd[j] != h.history[i][j] {
+		if len(h.history[i].Command) == len(cmd) {
+			for j := range h.history[i].Command {
+				if cmd[j] != h.history[i].Command[j] {
 					continue HistLoop
 				}
 			}
@@ -50,5 +112,54 @@ func (h *memoryCommandHistory) GetHistoryEntry(index int) ([]string, bool) {
 		return nil, false
 	}
 
+	return h.history[index].Command, true
+}
+
+// GetEntry returns the entry with metadata at the given index.
+func (h *memoryCommandHistory) GetEntry(index int) (HistoryEntry, bool) {
+	if index < 0 || index >= len(h.history) {
+		return HistoryEntry{}, false
+	}
 	return h.history[index], true
 }
+
+// Delete removes the entry at the given index. Newer entries are renumbered like in bash.
+func (h *memoryCommandHistory) Delete(index int) bool {
+	if index < 0 || index >= len(h.history) {
+		return false
+	}
+	for i := 0; i < index; i++ {
+		h.history[i].Number--
+	}
+	h.count--
+	h.history = append(h.history[:index], h.history[index+1:]...)
+	return true
+}
+
+// Clear removes all entries and restarts the numbering.
+func (h *memoryCommandHistory) Clear() {
+	h.history = make([]HistoryEntry, 0)
+	h.count = 0
+}
+
+// FindEvent returns the index of the entry with the given event number.
+func (h *memoryCommandHistory) FindEvent(number int) (int, bool) {
+	// numbers are descending from the latest entry
+	index := sort.Search(len(h.history), func(i int) bool {
+		return h.history[i].Number <= number
+	})
+	if index < len(h.history) && h.history[index].Number == number {
+		return index, true
+	}
+	return -1, false
+}
+
+// Search can be used as history search callback for ReadCommand.
+func (h *memoryCommandHistory) Search(query string, start int) (int, []string, bool) {
+	for i := max(start, 0); i < len(h.history); i++ {
+		if strings.Contains(GetCommandString(h.history[i].Command), query) {
+			return i, h.history[i].Command, true
+		}
+	}
+	return -1, nil, false
+}
@@ -2,6 +2,7 @@ package commandline
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -94,6 +95,59 @@ func TestCommandHistoryDeduplication(t *testing.T) {
 	requireHistEntryNil(t, hist, 4)
 }
 
+func TestCommandHistorySearch(t *testing.T) {
+	hist := NewCommandHistory(10)
+	hist.Put([]string{"echo", "foo bar"})
+	hist.Put([]string{"ls"})
+	hist.Put([]string{"echo", "foo"})
+
+	index, cmd, ok := hist.Search("echo", 0)
+	require.True(t, ok)
+	require.Equal(t, 0, index)
+	require.Equal(t, []string{"echo", "foo"}, cmd)
+
+	index, cmd, ok = hist.Search("echo", 1)
+	require.True(t, ok)
+	require.Equal(t, 2, index)
+	require.Equal(t, []string{"echo", "foo bar"}, cmd)
+
+	// commands are matched in quoted form
+	_, _, ok = hist.Search("\"foo bar\"", 0)
+	require.True(t, ok)
+
+	_, _, ok = hist.Search("echo", 3)
+	require.False(t, ok)
+}
+
+func TestLineHistorySearch(t *testing.T) {
+	hist := NewLineHistory(2)
+	hist.Put("foo")
+	hist.Put("bar")
+	hist.Put("baz")
+
+	index, line, ok := hist.Search("ba", 1)
+	require.True(t, ok)
+	require.Equal(t, 1, index)
+	require.Equal(t, "bar", line)
+
+	// evicted entries are not found
+	_, _, ok = hist.Search("foo", 0)
+	require.False(t, ok)
+}
+
+func TestLineHistoryKeepsDuplicates(t *testing.T) {
+	hist := NewLineHistory(5)
+	hist.Put("foo")
+	hist.Put("bar")
+	hist.Put("foo")
+
+	for i, expected := range []string{"foo", "bar", "foo"} {
+		line, ok := hist.GetHistoryEntry(i)
+		require.True(t, ok)
+		require.Equal(t, expected, line)
+	}
+}
+
 func requireHistEntry(t *testing.T, hist CommandHistory, i int, expected []string) {
 	cmd, ok := hist.GetHistoryEntry(i)
 	require.True(t, ok)
@@ -105,3 +159,61 @@ func requireHistEntryNil(t *testing.T, hist CommandHistory, i int) {
 	require.False(t, ok)
 	require.Nil(t, cmd)
 }
+
+func TestCommandHistoryMetadata(t *testing.T) {
+	hist := NewCommandHistory(10).(MetadataCommandHistory)
+	start := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	hist.PutEntry(HistoryEntry{Command: []string{"foo"}, Time: start, Duration: time.Second, Errored: true, WorkingDir: "/tmp"})
+	hist.Put([]string{"bar"})
+	hist.Put([]string{"baz"})
+
+	entry, ok := hist.GetEntry(2)
+	require.True(t, ok)
+	require.Equal(t, HistoryEntry{Command: []string{"foo"}, Time: start, Duration: time.Second, Errored: true, WorkingDir: "/tmp", Number: 1}, entry)
+	entry, ok = hist.GetEntry(0)
+	require.True(t, ok)
+	require.Equal(t, HistoryEntry{Command: []string{"baz"}, Number: 3}, entry)
+	_, ok = hist.GetEntry(3)
+	require.False(t, ok)
+
+	require.True(t, hist.Delete(1))
+	require.False(t, hist.Delete(2))
+	requireHistEntry(t, hist, 0, []string{"baz"})
+	requireHistEntry(t, hist, 1, []string{"foo"})
+	requireHistEntryNil(t, hist, 2)
+
+	hist.Clear()
+	requireHistEntryNil(t, hist, 0)
+}
+
+func TestCommandHistoryEventNumbers(t *testing.T) {
+	hist := NewCommandHistory(3).(MetadataCommandHistory)
+	for _, cmd := range []string{"a", "b", "c", "b", "d"} {
+		hist.Put([]string{cmd})
+	}
+
+	// numbers are kept when duplicates are erased and old entries are dropped
+	for number, cmd := range map[int]string{3: "c", 4: "b", 5: "d"} {
+		index, ok := hist.FindEvent(number)
+		require.True(t, ok, number)
+		requireHistEntry(t, hist, index, []string{cmd})
+	}
+	for _, number := range []int{0, 1, 2, 6} {
+		_, ok := hist.FindEvent(number)
+		require.False(t, ok, number)
+	}
+
+	// newer entries are renumbered after deletion
+	index, _ := hist.FindEvent(4)
+	require.True(t, hist.Delete(index))
+	index, ok := hist.FindEvent(4)
+	require.True(t, ok)
+	requireHistEntry(t, hist, index, []string{"d"})
+
+	hist.Clear()
+	hist.Put([]string{"e"})
+	index, ok = hist.FindEvent(1)
+	require.True(t, ok)
+	requireHistEntry(t, hist, index, []string{"e"})
+}
@@ -0,0 +1,113 @@
+package commandline
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	historyTimeFormat = "2006-01-02 15:04:05"
+)
+
+// NewHistoryCommand returns a named command to list, search, delete and clear entries of the command history of the environment.
+func NewHistoryCommand(name string, env *Environment) Command {
+	return &customCommand{
+		name,
+		func(cmd []string, index int) []CompletionOption {
+			if index == 1 {
+				return []CompletionOption{
+					NewDescribedCompletionOption("-c", "clear history", false),
+					NewDescribedCompletionOption("-d", "delete entry", false),
+					NewDescribedCompletionOption("-s", "search entries", false),
+					NewDescribedCompletionOption("-v", "show working directory", false),
+				}
+			}
+			return nil
+		},
+		func(args []string) error {
+			return execHistoryCommand(name, env, args)
+		},
+		"show and edit command history",
+	}
+}
+
+func execHistoryCommand(name string, env *Environment, args []string) error {
+	verbose := false
+	if len(args) > 0 && args[0] == "-v" {
+		verbose = true
+		args = args[1:]
+	}
+
+	switch {
+	case len(args) == 0:
+		return printHistory(env, "", verbose)
+	case len(args) == 2 && args[0] == "-s":
+		return printHistory(env, args[1], verbose)
+
+	case len(args) == 2 && args[0] == "-d" && !verbose:
+		history, ok := env.history.(MetadataCommandHistory)
+		if !ok {
+			return fmt.Errorf("history cannot be edited")
+		}
+		number, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("history entry %q not found", args[1])
+		}
+		index, ok := history.FindEvent(number)
+		if !ok || !history.Delete(index) {
+			return fmt.Errorf("history entry %q not found", args[1])
+		}
+		// saving the command would renumber the entries again
+		env.skipHistory = true
+		return nil
+
+	case len(args) == 1 && args[0] == "-c" && !verbose:
+		history, ok := env.history.(MetadataCommandHistory)
+		if !ok {
+			return fmt.Errorf("history cannot be edited")
+		}
+		history.Clear()
+		env.skipHistory = true
+		return nil
+	}
+
+	return fmt.Errorf("usage: %s [-v] [-s TEXT] | -d NUMBER | -c", name)
+}
+
+// printHistory lists all history entries containing query, oldest first.
+func printHistory(env *Environment, query string, verbose bool) error {
+	history, hasMetadata := env.history.(MetadataCommandHistory)
+	count := historyLen(env.history.GetHistoryEntry)
+
+	for index := count - 1; index >= 0; index-- {
+		entry := HistoryEntry{Number: count - index}
+		if hasMetadata {
+			entry, _ = history.GetEntry(index)
+		} else {
+			entry.Command, _ = env.history.GetHistoryEntry(index)
+		}
+
+		cmd := GetCommandString(entry.Command)
+		if !strings.Contains(cmd, query) {
+			continue
+		}
+
+		line := fmt.Sprintf("%5d  %s", entry.Number, cmd)
+		if !entry.Time.IsZero() {
+			status := "ok"
+			if entry.Errored {
+				status = "error"
+			}
+			line = fmt.Sprintf("%5d  %s  %9s  %-5s  %s", entry.Number, entry.Time.Local().Format(historyTimeFormat), entry.Duration.Round(time.Millisecond), status, cmd)
+		}
+		if verbose && len(entry.WorkingDir) > 0 {
+			line += "  (" + entry.WorkingDir + ")"
+		}
+		if _, err := env.Console.Println(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package commandline
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sbreitf1/go-console/consoletest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoryCommand(t *testing.T) {
+	c, input, output := consoletest.NewMockConsole()
+	input.PutString("print 1\nfail\nhistory\n")
+	input.PutString("history -d 1\nhistory -s fa\nhistory -s hist\n")
+	input.PutString("history -d 42\nhistory -c\nhistory\nexit\n")
+
+	cle, _, _ := prepareTestCLE()
+	cle.Console = c
+	cle.SetStaticPrompt("")
+	cle.RegisterCommand(NewParameterlessCommand("fail", func([]string) error { return fmt.Errorf("failed") }))
+	cle.RegisterCommand(NewHistoryCommand("history", cle))
+	assert.NoError(t, cle.Run())
+	input.AssertBufferConsumed(t)
+
+	lines := strings.Split(output.String(), "\n")
+	assert.Equal(t, "> history", lines[3])
+	assert.Regexp(t, `^    1  \d{4}-\d\d-\d\d \d\d:\d\d:\d\d  +\d+(\.\d+)?m?s  ok     print 1$`, lines[4])
+	assert.Regexp(t, `^    2  \d{4}-\d\d-\d\d \d\d:\d\d:\d\d  +\d+(\.\d+)?m?s  error  fail$`, lines[5])
+	assert.Equal(t, "> history -d 1", lines[6])
+	assert.Equal(t, "> history -s fa", lines[7])
+	// entries have been renumbered after deletion
+	assert.Regexp(t, `^    1  .*  error  fail$`, lines[8])
+	// deletion is not saved to history
+	assert.Equal(t, "> history -s hist", lines[9])
+	assert.Regexp(t, `^    2  .*  ok     history$`, lines[10])
+	assert.Regexp(t, `^    3  .*  ok     history -s fa$`, lines[11])
+	assert.Equal(t, "> history -d 42", lines[12])
+	assert.Equal(t, "ERROR: history entry \"42\" not found", lines[13])
+	// clearing is not saved to history
+	assert.Equal(t, []string{"> history -c", "> history", "> exit", ""}, lines[14:])
+}
+
+func TestHistoryCommandWithoutMetadata(t *testing.T) {
+	c, input, output := consoletest.NewMockConsole()
+	input.PutString("history\nhistory -c\nexit\n")
+
+	history := &plainCommandHistory{NewCommandHistory(10)}
+	history.Put([]string{"print", "foo bar"})
+	cle, _, _ := prepareTestCLE()
+	cle.Console = c
+	cle.SetStaticPrompt("")
+	cle.SetHistory(history)
+	cle.RegisterCommand(NewHistoryCommand("history", cle))
+	assert.NoError(t, cle.Run())
+	input.AssertBufferConsumed(t)
+
+	assert.Equal(t, "> history\n    1  print \"foo bar\"\n> history -c\nERROR: history cannot be edited\n> exit\n", output.String())
+}
+
+// plainCommandHistory hides the metadata methods of a command history.
+type plainCommandHistory struct {
+	CommandHistory
+}
@@ -0,0 +1,393 @@
+package commandline
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// historyFile stores history entries as JSON encoded lines. All access is synchronized with other processes using advisory file locks.
+type historyFile struct {
+	path string
+}
+
+// Load returns all stored lines, oldest first. A missing file is treated as empty history.
+func (h historyFile) Load() ([]string, error) {
+	f, err := os.Open(h.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	if err := lockFile(f, false); err != nil {
+		return nil, err
+	}
+	defer unlockFile(f)
+
+	return readHistoryLines(f)
+}
+
+// Append adds a line to the file and rewrites it with the lines returned by update unless nil is returned.
+func (h historyFile) Append(line string, update func(lines []string) []string) error {
+	f, err := os.OpenFile(h.path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := lockFile(f, true); err != nil {
+		return err
+	}
+	defer unlockFile(f)
+
+	lines, err := readHistoryLines(f)
+	if err != nil {
+		return err
+	}
+
+	// file offset is at the end of the file after reading
+	terminated, err := endsWithNewline(f)
+	if err != nil {
+		return err
+	}
+	data := line + "\n"
+	if !terminated {
+		// previous write has been interrupted
+		data = "\n" + data
+	}
+	if _, err := f.WriteString(data); err != nil {
+		return err
+	}
+	lines = append(lines, line)
+
+	if compacted := update(lines); compacted != nil {
+		return writeHistoryLines(f, compacted)
+	}
+	return nil
+}
+
+// endsWithNewline returns true if f is empty or the byte before the current offset is a line break.
+func endsWithNewline(f *os.File) (bool, error) {
+	offset, err := f.Seek(0, io.SeekCurrent)
+	if err != nil || offset == 0 {
+		return true, err
+	}
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, offset-1); err != nil {
+		return false, err
+	}
+	return last[0] == '\n', nil
+}
+
+// writeHistoryLines replaces the content of f with the given lines.
+func writeHistoryLines(f *os.File, lines []string) error {
+	var buf bytes.Buffer
+	for _, l := range lines {
+		buf.WriteString(l)
+		buf.WriteByte('\n')
+	}
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	_, err := f.WriteAt(buf.Bytes(), 0)
+	return err
+}
+
+// Rewrite calls update with all stored lines and replaces the file content with the returned lines unless update returns nil.
+func (h historyFile) Rewrite(update func(lines []string) []string) error {
+	f, err := os.OpenFile(h.path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := lockFile(f, true); err != nil {
+		return err
+	}
+	defer unlockFile(f)
+
+	lines, err := readHistoryLines(f)
+	if err != nil {
+		return err
+	}
+	if updated := update(lines); updated != nil {
+		return writeHistoryLines(f, updated)
+	}
+	return nil
+}
+
+// readHistoryLines returns all non-empty lines of r. Lines are not limited in length.
+func readHistoryLines(r io.Reader) ([]string, error) {
+	lines := make([]string, 0)
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if line = strings.TrimSuffix(line, "\n"); len(line) > 0 {
+			lines = append(lines, line)
+		}
+		if err == io.EOF {
+			return lines, nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+}
+
+type fileCommandHistory struct {
+	memoryCommandHistory
+	file historyFile
+	// base denotes the event number before the first entry of the file. It keeps the numbers of newer entries when the file is rewritten.
+	base int
+}
+
+// historyFileEntry is the stored form of an entry with metadata. Entries without metadata are stored as plain JSON array.
+type historyFileEntry struct {
+	Command    []string   `json:"cmd"`
+	Time       *time.Time `json:"time,omitempty"`
+	DurationMs int64      `json:"durationMs,omitempty"`
+	Errored    bool       `json:"errored,omitempty"`
+	WorkingDir string     `json:"dir,omitempty"`
+}
+
+// NewFileCommandHistory returns a command history like NewCommandHistory that is persisted in the given file and can be shared by processes.
+func NewFileCommandHistory(path string, maxCount int) (CommandHistory, error) {
+	return NewFileCommandHistoryWithOptions(path, maxCount, HistoryOptions{EraseDuplicates: true})
+}
+
+// NewFileCommandHistoryWithOptions returns a command history like NewFileCommandHistory that only saves entries accepted by opts.
+func NewFileCommandHistoryWithOptions(path string, maxCount int, opts HistoryOptions) (CommandHistory, error) {
+	h := &fileCommandHistory{memoryCommandHistory{maxCount, make([]HistoryEntry, 0), opts, 0}, historyFile{path}, 0}
+
+	lines, err := h.file.Load()
+	if err != nil {
+		return nil, err
+	}
+	h.load(lines)
+	return h, nil
+}
+
+func (h *fileCommandHistory) load(lines []string) {
+	h.history = make([]HistoryEntry, 0)
+	h.count = h.base
+	for _, line := range lines {
+		entry, err := decodeHistoryEntry(line)
+		if err != nil {
+			// skip corrupted entries
+			continue
+		}
+		h.insert(entry)
+	}
+}
+
+// encodeAll returns the stored form of all entries, oldest first, to rewrite the file.
+func (h *fileCommandHistory) encodeAll() []string {
+	lines := make([]string, 0, len(h.history))
+	for i := len(h.history) - 1; i >= 0; i-- {
+		if line, err := encodeHistoryEntry(h.history[i]); err == nil {
+			lines = append(lines, line)
+		}
+	}
+	h.base = h.count - len(lines)
+	return lines
+}
+
+// Put saves a new command to the history as latest entry and appends it to the history file.
+func (h *fileCommandHistory) Put(cmd []string) {
+	h.PutEntry(HistoryEntry{Command: cmd})
+}
+
+// PutEntry saves a new entry to the history as latest entry and appends it to the history file.
+func (h *fileCommandHistory) PutEntry(entry HistoryEntry) {
+	// filter before writing to make sure secrets never reach the file
+	entry, ok := h.opts.applyCommand(entry)
+	if !ok {
+		return
+	}
+	if entry.Command == nil {
+		entry.Command = []string{}
+	}
+	line, err := encodeHistoryEntry(entry)
+	if err != nil {
+		h.insert(entry)
+		return
+	}
+
+	updated := false
+	if err := h.file.Append(line, func(lines []string) []string {
+		h.load(lines)
+		updated = true
+		if len(lines) <= h.maxCount {
+			return nil
+		}
+
+		// compact file to remaining entries
+		return h.encodeAll()
+	}); err != nil && !updated {
+		h.insert(entry)
+	}
+}
+
+// Delete removes the entry at the given index from the history and the entry with equal content from the history file.
+func (h *fileCommandHistory) Delete(index int) bool {
+	target, ok := h.GetEntry(index)
+	if !ok {
+		return false
+	}
+	line, err := encodeHistoryEntry(target)
+	if err != nil {
+		return h.memoryCommandHistory.Delete(index)
+	}
+	// equal entries before the target are skipped to delete the same occurrence
+	skip := 0
+	for i := 0; i < index; i++ {
+		if other, err := encodeHistoryEntry(h.history[i]); err == nil && other == line {
+			skip++
+		}
+	}
+
+	loaded, deleted := false, false
+	if err := h.file.Rewrite(func(lines []string) []string {
+		h.load(lines)
+		loaded = true
+		for i := range h.history {
+			if other, err := encodeHistoryEntry(h.history[i]); err == nil && other == line {
+				if skip == 0 {
+					deleted = h.memoryCommandHistory.Delete(i)
+					return h.encodeAll()
+				}
+				skip--
+			}
+		}
+		return nil
+	}); err != nil && !loaded {
+		return h.memoryCommandHistory.Delete(index)
+	}
+	return deleted
+}
+
+// Clear removes all entries from the history and the history file.
+func (h *fileCommandHistory) Clear() {
+	h.memoryCommandHistory.Clear()
+	h.base = 0
+	h.file.Rewrite(func([]string) []string {
+		return []string{}
+	})
+}
+
+func encodeHistoryEntry(entry HistoryEntry) (string, error) {
+	cmd := entry.Command
+	if cmd == nil {
+		cmd = []string{}
+	}
+
+	var data []byte
+	var err error
+	if entry.Time.IsZero() && entry.Duration == 0 && !entry.Errored && len(entry.WorkingDir) == 0 {
+		data, err = json.Marshal(cmd)
+	} else {
+		fileEntry := historyFileEntry{Command: cmd, DurationMs: entry.Duration.Milliseconds(), Errored: entry.Errored, WorkingDir: entry.WorkingDir}
+		if !entry.Time.IsZero() {
+			fileEntry.Time = &entry.Time
+		}
+		data, err = json.Marshal(fileEntry)
+	}
+	return string(data), err
+}
+
+func decodeHistoryEntry(line string) (HistoryEntry, error) {
+	if !strings.HasPrefix(line, "{") {
+		var cmd []string
+		if err := json.Unmarshal([]byte(line), &cmd); err != nil {
+			return HistoryEntry{}, err
+		}
+		return HistoryEntry{Command: cmd}, nil
+	}
+
+	var fileEntry historyFileEntry
+	if err := json.Unmarshal([]byte(line), &fileEntry); err != nil {
+		return HistoryEntry{}, err
+	}
+	entry := HistoryEntry{Command: fileEntry.Command, Duration: time.Duration(fileEntry.DurationMs) * time.Millisecond, Errored: fileEntry.Errored, WorkingDir: fileEntry.WorkingDir}
+	if fileEntry.Time != nil {
+		entry.Time = *fileEntry.Time
+	}
+	if entry.Command == nil {
+		entry.Command = []string{}
+	}
+	return entry, nil
+}
+
+type fileLineHistory struct {
+	memoryLineHistory
+	file historyFile
+}
+
+// NewFileLineHistory returns a line history like NewLineHistory that is persisted in the given file and can be shared by processes.
+func NewFileLineHistory(path string, maxCount int) (LineHistory, error) {
+	return NewFileLineHistoryWithOptions(path, maxCount, HistoryOptions{})
+}
+
+// NewFileLineHistoryWithOptions returns a line history like NewFileLineHistory that only saves lines accepted by opts.
+func NewFileLineHistoryWithOptions(path string, maxCount int, opts HistoryOptions) (LineHistory, error) {
+	h := &fileLineHistory{memoryLineHistory{maxCount, make([]string, 0), opts}, historyFile{path}}
+
+	lines, err := h.file.Load()
+	if err != nil {
+		return nil, err
+	}
+	h.load(lines)
+	return h, nil
+}
+
+func (h *fileLineHistory) load(lines []string) {
+	h.history = make([]string, 0)
+	for _, line := range lines {
+		var str string
+		if err := json.Unmarshal([]byte(line), &str); err != nil {
+			// skip corrupted entries
+			continue
+		}
+		h.insert(str)
+	}
+}
+
+// Put saves a new line to the history as latest entry and appends it to the history file.
+func (h *fileLineHistory) Put(line string) {
+	// filter before writing to make sure secrets never reach the file
+	line, ok := h.opts.apply(line)
+	if !ok {
+		return
+	}
+	data, err := json.Marshal(line)
+	if err != nil {
+		h.insert(line)
+		return
+	}
+
+	updated := false
+	if err := h.file.Append(string(data), func(lines []string) []string {
+		h.load(lines)
+		updated = true
+		if len(lines) <= h.maxCount {
+			return nil
+		}
+
+		// compact file to remaining entries
+		compacted := make([]string, 0, len(h.history))
+		for i := len(h.history) - 1; i >= 0; i-- {
+			if data, err := json.Marshal(h.history[i]); err == nil {
+				compacted = append(compacted, string(data))
+			}
+		}
+		return compacted
+	}); err != nil && !updated {
+		h.insert(line)
+	}
+}
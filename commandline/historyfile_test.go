@@ -0,0 +1,242 @@
+package commandline
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileCommandHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+
+	hist, err := NewFileCommandHistory(path, 3)
+	require.NoError(t, err)
+	requireHistEntryNil(t, hist, 0)
+
+	hist.Put([]string{"foo", "bar baz"})
+	hist.Put([]string{"42"})
+	hist.Put([]string{"foo", "bar baz"})
+	requireHistEntry(t, hist, 0, []string{"foo", "bar baz"})
+	requireHistEntry(t, hist, 1, []string{"42"})
+	requireHistEntryNil(t, hist, 2)
+
+	// reload from file
+	hist, err = NewFileCommandHistory(path, 3)
+	require.NoError(t, err)
+	requireHistEntry(t, hist, 0, []string{"foo", "bar baz"})
+	requireHistEntry(t, hist, 1, []string{"42"})
+	requireHistEntryNil(t, hist, 2)
+}
+
+func TestFileCommandHistoryCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+
+	hist, err := NewFileCommandHistory(path, 2)
+	require.NoError(t, err)
+	hist.Put([]string{"1"})
+	hist.Put([]string{"2"})
+	hist.Put([]string{"3"})
+	hist.Put([]string{"4"})
+
+	assert.Equal(t, "[\"3\"]\n[\"4\"]\n", readFile(t, path))
+	requireHistEntry(t, hist, 0, []string{"4"})
+	requireHistEntry(t, hist, 1, []string{"3"})
+	requireHistEntryNil(t, hist, 2)
+}
+
+func TestFileCommandHistoryMerge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+
+	hist1, err := NewFileCommandHistory(path, 10)
+	require.NoError(t, err)
+	hist2, err := NewFileCommandHistory(path, 10)
+	require.NoError(t, err)
+
+	hist1.Put([]string{"first"})
+	hist2.Put([]string{"second"})
+	hist1.Put([]string{"third"})
+
+	requireHistEntry(t, hist1, 0, []string{"third"})
+	requireHistEntry(t, hist1, 1, []string{"second"})
+	requireHistEntry(t, hist1, 2, []string{"first"})
+	// hist2 has not seen the latest entry of hist1 yet
+	requireHistEntry(t, hist2, 0, []string{"second"})
+	requireHistEntry(t, hist2, 1, []string{"first"})
+}
+
+func TestFileCommandHistoryDeleteMerged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+
+	hist1, err := NewFileCommandHistoryWithOptions(path, 10, HistoryOptions{})
+	require.NoError(t, err)
+	hist2, err := NewFileCommandHistoryWithOptions(path, 10, HistoryOptions{})
+	require.NoError(t, err)
+	hist1.Put([]string{"a"})
+	hist1.Put([]string{"b"})
+	hist1.Put([]string{"a"})
+	hist2.Put([]string{"c"})
+
+	// index refers to the entries seen by hist1
+	assert.True(t, hist1.(MetadataCommandHistory).Delete(1))
+	assert.Equal(t, "[\"a\"]\n[\"a\"]\n[\"c\"]\n", readFile(t, path))
+	requireHistEntry(t, hist1, 0, []string{"c"})
+	requireHistEntry(t, hist1, 1, []string{"a"})
+	requireHistEntry(t, hist1, 2, []string{"a"})
+
+	// the second occurrence of a is deleted
+	hist2.Put([]string{"d"})
+	assert.True(t, hist1.(MetadataCommandHistory).Delete(2))
+	assert.Equal(t, "[\"a\"]\n[\"c\"]\n[\"d\"]\n", readFile(t, path))
+
+	// entry has already been deleted by another process
+	requireHistEntry(t, hist2, 2, []string{"a"})
+	assert.True(t, hist1.(MetadataCommandHistory).Delete(2))
+	assert.False(t, hist2.(MetadataCommandHistory).Delete(3))
+	assert.Equal(t, "[\"c\"]\n[\"d\"]\n", readFile(t, path))
+}
+
+func TestFileCommandHistoryEventNumbers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+
+	hist, err := NewFileCommandHistory(path, 2)
+	require.NoError(t, err)
+	for _, cmd := range []string{"a", "b", "c", "d"} {
+		hist.Put([]string{cmd})
+	}
+	// numbers are kept when the file is compacted
+	index, ok := hist.(MetadataCommandHistory).FindEvent(4)
+	require.True(t, ok)
+	requireHistEntry(t, hist, index, []string{"d"})
+	hist.Put([]string{"e"})
+	index, ok = hist.(MetadataCommandHistory).FindEvent(4)
+	require.True(t, ok)
+	requireHistEntry(t, hist, index, []string{"d"})
+	_, ok = hist.(MetadataCommandHistory).FindEvent(3)
+	require.False(t, ok)
+}
+
+func TestFileCommandHistoryConcurrentPut(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		hist, err := NewFileCommandHistory(path, 1000)
+		require.NoError(t, err)
+
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				hist.Put([]string{name, strings.Repeat("x", j)})
+			}
+		}(string(rune('a' + i)))
+	}
+	wg.Wait()
+
+	assert.Len(t, strings.Split(strings.TrimSpace(readFile(t, path)), "\n"), 100)
+}
+
+func TestFileCommandHistorySkipCorruptedEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	require.NoError(t, os.WriteFile(path, []byte("[\"foo\"]\n{broken\n\n[\"bar\"]\n"), 0600))
+
+	hist, err := NewFileCommandHistory(path, 10)
+	require.NoError(t, err)
+	requireHistEntry(t, hist, 0, []string{"bar"})
+	requireHistEntry(t, hist, 1, []string{"foo"})
+	requireHistEntryNil(t, hist, 2)
+}
+
+func TestFileCommandHistoryUnterminatedLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	require.NoError(t, os.WriteFile(path, []byte("[\"foo\"]"), 0600))
+
+	hist, err := NewFileCommandHistory(path, 10)
+	require.NoError(t, err)
+	hist.Put([]string{"bar"})
+	assert.Equal(t, "[\"foo\"]\n[\"bar\"]\n", readFile(t, path))
+	requireHistEntry(t, hist, 0, []string{"bar"})
+	requireHistEntry(t, hist, 1, []string{"foo"})
+}
+
+func TestFileCommandHistoryLongEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	long := strings.Repeat("x", 2*1024*1024)
+
+	hist, err := NewFileCommandHistory(path, 10)
+	require.NoError(t, err)
+	hist.Put([]string{long})
+	hist.Put([]string{"foo"})
+
+	hist, err = NewFileCommandHistory(path, 10)
+	require.NoError(t, err)
+	requireHistEntry(t, hist, 0, []string{"foo"})
+	requireHistEntry(t, hist, 1, []string{long})
+}
+
+func TestFileLineHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+
+	hist, err := NewFileLineHistory(path, 2)
+	require.NoError(t, err)
+	hist.Put("foo")
+	hist.Put("multi\nline")
+	hist.Put("bar")
+
+	hist, err = NewFileLineHistory(path, 2)
+	require.NoError(t, err)
+	requireLineHistEntry(t, hist, 0, "bar")
+	requireLineHistEntry(t, hist, 1, "multi\nline")
+	_, ok := hist.GetHistoryEntry(2)
+	assert.False(t, ok)
+	assert.Equal(t, "\"multi\\nline\"\n\"bar\"\n", readFile(t, path))
+}
+
+func requireLineHistEntry(t *testing.T, hist LineHistory, i int, expected string) {
+	line, ok := hist.GetHistoryEntry(i)
+	require.True(t, ok)
+	require.Equal(t, expected, line)
+}
+
+func readFile(t *testing.T, path string) string {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestFileCommandHistoryMetadata(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	start := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	hist, err := NewFileCommandHistory(path, 10)
+	require.NoError(t, err)
+	hist.Put([]string{"plain"})
+	hist.(MetadataCommandHistory).PutEntry(HistoryEntry{Command: []string{"deploy", "prod"}, Time: start, Duration: 1500 * time.Millisecond, Errored: true, WorkingDir: "/srv"})
+	hist.Put([]string{"other"})
+
+	hist, err = NewFileCommandHistory(path, 10)
+	require.NoError(t, err)
+	entry, ok := hist.(MetadataCommandHistory).GetEntry(1)
+	require.True(t, ok)
+	assert.Equal(t, []string{"deploy", "prod"}, entry.Command)
+	assert.True(t, start.Equal(entry.Time))
+	assert.Equal(t, 1500*time.Millisecond, entry.Duration)
+	assert.True(t, entry.Errored)
+	assert.Equal(t, "/srv", entry.WorkingDir)
+	requireHistEntry(t, hist, 2, []string{"plain"})
+
+	assert.True(t, hist.(MetadataCommandHistory).Delete(1))
+	assert.Equal(t, "[\"plain\"]\n[\"other\"]\n", readFile(t, path))
+
+	hist.(MetadataCommandHistory).Clear()
+	assert.Equal(t, "", readFile(t, path))
+	hist, err = NewFileCommandHistory(path, 10)
+	require.NoError(t, err)
+	requireHistEntryNil(t, hist, 0)
+}
@@ -0,0 +1,99 @@
+package commandline
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	// RedactedText replaces secrets matched by HistoryOptions.RedactPatterns.
+	RedactedText = "***"
+)
+
+// HistoryOptions configures which entries are saved to a history.
+type HistoryOptions struct {
+	// EraseDuplicates sets whether older entries equal to a new entry are removed.
+	EraseDuplicates bool
+	// IgnoreSpace sets whether entries starting with whitespace are not saved.
+	IgnoreSpace bool
+	// IgnorePatterns denotes expressions for entries that are not saved.
+	IgnorePatterns []*regexp.Regexp
+	// RedactPatterns denotes expressions for secrets that are replaced by RedactedText. Only submatches are replaced for expressions with groups.
+	RedactPatterns []*regexp.Regexp
+	// Filter is called for every entry after redaction and returns the entry to save. Return false to not save the entry at all. Can be nil.
+	Filter func(entry string) (string, bool)
+}
+
+// apply returns the entry to save to history or false if the entry must not be saved.
+func (o *HistoryOptions) apply(entry string) (string, bool) {
+	if o.IgnoreSpace && len(entry) > 0 && unicode.IsSpace([]rune(entry)[0]) {
+		return "", false
+	}
+	for _, pattern := range o.IgnorePatterns {
+		if pattern.MatchString(entry) {
+			return "", false
+		}
+	}
+	for _, pattern := range o.RedactPatterns {
+		entry = redact(pattern, entry)
+	}
+	if o.Filter != nil {
+		return o.Filter(entry)
+	}
+	return entry, true
+}
+
+// applyCommand returns the entry to save to command history or false if the entry must not be saved.
+func (o *HistoryOptions) applyCommand(entry HistoryEntry) (HistoryEntry, bool) {
+	str := entry.Input
+	if len(str) == 0 {
+		str = GetCommandString(entry.Command)
+	}
+
+	filtered, ok := o.apply(str)
+	if !ok {
+		return HistoryEntry{}, false
+	}
+	if filtered != str {
+		cmd, isComplete := ParseCommand(filtered)
+		if !isComplete || len(cmd) == 0 {
+			return HistoryEntry{}, false
+		}
+		entry.Command = cmd
+		if len(entry.Input) > 0 {
+			entry.Input = filtered
+		}
+	}
+	return entry, true
+}
+
+// redact replaces all matches of pattern in str by RedactedText. For patterns with groups, only the submatches are replaced.
+func redact(pattern *regexp.Regexp, str string) string {
+	matches := pattern.FindAllStringSubmatchIndex(str, -1)
+	if len(matches) == 0 {
+		return str
+	}
+
+	var sb strings.Builder
+	pos := 0
+	for _, m := range matches {
+		if len(m) == 2 {
+			sb.WriteString(str[pos:m[0]])
+			sb.WriteString(RedactedText)
+			pos = m[1]
+			continue
+		}
+		for i := 2; i < len(m); i += 2 {
+			if m[i] < pos {
+				// group did not participate in match or overlaps a previous group
+				continue
+			}
+			sb.WriteString(str[pos:m[i]])
+			sb.WriteString(RedactedText)
+			pos = m[i+1]
+		}
+	}
+	sb.WriteString(str[pos:])
+	return sb.String()
+}
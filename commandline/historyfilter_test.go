@@ -0,0 +1,144 @@
+package commandline
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/sbreitf1/go-console"
+	"github.com/sbreitf1/go-console/consoletest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedact(t *testing.T) {
+	password := regexp.MustCompile(`--password\s+(\S+)`)
+	token := regexp.MustCompile(`token=\w+`)
+
+	assert.Equal(t, "login --password *** --user foo", redact(password, "login --password xyz --user foo"))
+	assert.Equal(t, "a --password *** b --password ***", redact(password, "a --password x b --password y"))
+	assert.Equal(t, "curl ?***&a=b", redact(token, "curl ?token=abc&a=b"))
+	assert.Equal(t, "ls", redact(password, "ls"))
+}
+
+func TestCommandHistoryOptions(t *testing.T) {
+	hist := NewCommandHistoryWithOptions(10, HistoryOptions{
+		IgnoreSpace:    true,
+		IgnorePatterns: []*regexp.Regexp{regexp.MustCompile(`^exit$`)},
+		RedactPatterns: []*regexp.Regexp{regexp.MustCompile(`--password\s+(\S+)`)},
+		Filter: func(entry string) (string, bool) {
+			return entry, !strings.HasPrefix(entry, "secret")
+		},
+	}).(MetadataCommandHistory)
+
+	hist.Put([]string{"ls"})
+	hist.Put([]string{"ls"})
+	hist.PutEntry(HistoryEntry{Command: []string{"rm", "foo"}, Input: " rm foo"})
+	hist.Put([]string{"exit"})
+	hist.Put([]string{"secret", "stuff"})
+	hist.PutEntry(HistoryEntry{Command: []string{"login", "--password", "xyz"}, Input: "login --password xyz"})
+
+	requireHistEntry(t, hist, 0, []string{"login", "--password", "***"})
+	entry, _ := hist.GetEntry(0)
+	assert.Equal(t, "login --password ***", entry.Input)
+	// duplicates are kept
+	requireHistEntry(t, hist, 1, []string{"ls"})
+	requireHistEntry(t, hist, 2, []string{"ls"})
+	requireHistEntryNil(t, hist, 3)
+
+	// quoted form is matched without input
+	hist.Put([]string{"login", "--password", "abc", "-v"})
+	requireHistEntry(t, hist, 0, []string{"login", "--password", "***", "-v"})
+
+	// entries with partially redacted quotes are dropped
+	hist.Put([]string{"login", "--password", "x y"})
+	requireHistEntry(t, hist, 0, []string{"login", "--password", "***", "-v"})
+}
+
+func TestLineHistoryOptions(t *testing.T) {
+	hist := NewLineHistoryWithOptions(10, HistoryOptions{
+		EraseDuplicates: true,
+		IgnoreSpace:     true,
+		RedactPatterns:  []*regexp.Regexp{regexp.MustCompile(`password=\S+`)},
+	})
+
+	hist.Put("foo")
+	hist.Put("bar")
+	hist.Put("foo")
+	hist.Put(" hidden")
+	hist.Put("\thidden")
+	hist.Put("set password=xyz")
+
+	requireLineHistEntry(t, hist, 0, "set ***")
+	requireLineHistEntry(t, hist, 1, "foo")
+	requireLineHistEntry(t, hist, 2, "bar")
+	_, ok := hist.GetHistoryEntry(3)
+	assert.False(t, ok)
+}
+
+func TestFileHistoryOptions(t *testing.T) {
+	dir := t.TempDir()
+	opts := HistoryOptions{RedactPatterns: []*regexp.Regexp{regexp.MustCompile(`--password\s+(\S+)`)}}
+
+	cmdHist, err := NewFileCommandHistoryWithOptions(filepath.Join(dir, "commands"), 10, opts)
+	require.NoError(t, err)
+	cmdHist.Put([]string{"login", "--password", "xyz"})
+	requireHistEntry(t, cmdHist, 0, []string{"login", "--password", "***"})
+	assert.NotContains(t, readFile(t, filepath.Join(dir, "commands")), "xyz")
+
+	lineHist, err := NewFileLineHistoryWithOptions(filepath.Join(dir, "lines"), 10, opts)
+	require.NoError(t, err)
+	lineHist.Put("login --password xyz")
+	requireLineHistEntry(t, lineHist, 0, "login --password ***")
+	assert.NotContains(t, readFile(t, filepath.Join(dir, "lines")), "xyz")
+}
+
+func TestCommandLineEnvironmentHistoryIgnoreSpace(t *testing.T) {
+	consoletest.WithMocks(func(input *consoletest.MockInput) {
+		history := NewCommandHistoryWithOptions(10, HistoryOptions{EraseDuplicates: true, IgnoreSpace: true})
+
+		cle, _, sb := prepareTestCLE()
+		cle.SetHistory(history)
+		input.PutString("print visible\n")
+		input.PutString(" print hidden\n")
+		input.PutKeys(console.KeyUp, console.KeyEnter)
+		input.PutString(" exit\n")
+		assert.NoError(t, cle.Run())
+		assert.Equal(t, ">visible<|>hidden<|>visible<|", sb.String())
+		requireHistEntry(t, history, 0, []string{"print", "visible"})
+		requireHistEntryNil(t, history, 1)
+		input.AssertBufferConsumed(t)
+	})
+}
+
+func TestFileHistoryOptionsOnlyFilterNewEntries(t *testing.T) {
+	dir := t.TempDir()
+	calls := 0
+	opts := HistoryOptions{Filter: func(entry string) (string, bool) {
+		calls++
+		return entry + "!", true
+	}}
+
+	cmdHist, err := NewFileCommandHistoryWithOptions(filepath.Join(dir, "commands"), 10, opts)
+	require.NoError(t, err)
+	cmdHist.Put([]string{"foo"})
+	cmdHist.Put([]string{"bar"})
+	requireHistEntry(t, cmdHist, 0, []string{"bar!"})
+	requireHistEntry(t, cmdHist, 1, []string{"foo!"})
+	_, err = NewFileCommandHistoryWithOptions(filepath.Join(dir, "commands"), 10, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	calls = 0
+	lineHist, err := NewFileLineHistoryWithOptions(filepath.Join(dir, "lines"), 10, opts)
+	require.NoError(t, err)
+	lineHist.Put("foo")
+	lineHist.Put("bar")
+	requireLineHistEntry(t, lineHist, 0, "bar!")
+	requireLineHistEntry(t, lineHist, 1, "foo!")
+	_, err = NewFileLineHistoryWithOptions(filepath.Join(dir, "lines"), 10, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,157 @@
+package commandline
+
+import (
+	"github.com/sbreitf1/go-console"
+)
+
+// EditorAction denotes a named action of the line editor that can be bound to keys.
+type EditorAction string
+
+// Built-in editor actions.
+const (
+	ActionSelfInsert      EditorAction = "self-insert"
+	ActionMoveLeft        EditorAction = "move-left"
+	ActionMoveRight       EditorAction = "move-right"
+	ActionMoveWordLeft    EditorAction = "move-word-left"
+	ActionMoveWordRight   EditorAction = "move-word-right"
+	ActionMoveToLineBegin EditorAction = "move-to-line-begin"
+	ActionMoveToLineEnd   EditorAction = "move-to-line-end"
+	ActionDeleteLeft      EditorAction = "delete-left"
+	ActionDeleteRight     EditorAction = "delete-right"
+	ActionClearLine       EditorAction = "clear-line"
+	ActionKillToLineEnd   EditorAction = "kill-to-line-end"
+	ActionKillToLineBegin EditorAction = "kill-to-line-begin"
+	ActionKillWordLeft    EditorAction = "kill-word-left"
+	ActionYank            EditorAction = "yank"
+	ActionYankPop         EditorAction = "yank-pop"
+	ActionTransposeChars  EditorAction = "transpose-chars"
+	ActionHistoryPrev     EditorAction = "history-prev"
+	ActionHistoryNext     EditorAction = "history-next"
+	ActionHistorySearch   EditorAction = "history-search"
+	ActionComplete        EditorAction = "complete"
+	ActionAcceptLine      EditorAction = "accept-line"
+	ActionCancel          EditorAction = "cancel"
+)
+
+// LineBuffer gives widgets access to the line being edited. Positions are rune indices.
+type LineBuffer interface {
+	// String returns the content of the line.
+	String() string
+	// Caret returns the position of the caret.
+	Caret() int
+	// SetCaret moves the caret to the given position.
+	SetCaret(pos int)
+	// InsertAtCaret inserts text at the caret and moves the caret behind it.
+	InsertAtCaret(str string)
+	// RemoveRange removes the runes from start to end and returns the removed text. The caret is moved to start.
+	RemoveRange(start, end int) string
+	// Replace sets the whole line content and moves the caret to the end of the line.
+	Replace(str string)
+}
+
+// Widget is a custom editor action that can read and modify the line being edited.
+type Widget func(line LineBuffer)
+
+// Keymap maps key events to editor actions.
+type Keymap struct {
+	bindings map[console.KeyEvent]EditorAction
+	widgets  map[EditorAction]Widget
+}
+
+var (
+	// defaultKeymap is used when no keymap is configured. It must not be modified.
+	defaultKeymap = DefaultKeymap()
+)
+
+// NewKeymap returns an empty keymap. Only regular characters are inserted.
+func NewKeymap() *Keymap {
+	return &Keymap{make(map[console.KeyEvent]EditorAction), make(map[EditorAction]Widget)}
+}
+
+// DefaultKeymap returns a new keymap with the default readline-like bindings that can be modified.
+func DefaultKeymap() *Keymap {
+	k := NewKeymap()
+	k.BindKey(console.KeyLeft, ActionMoveLeft)
+	k.BindKey(console.KeyCtrlB, ActionMoveLeft)
+	k.BindKey(console.KeyRight, ActionMoveRight)
+	k.BindKey(console.KeyCtrlF, ActionMoveRight)
+	k.Bind(console.KeyEvent{Key: console.KeyLeft, Modifiers: console.ModCtrl}, ActionMoveWordLeft)
+	k.Bind(console.KeyEvent{Rune: 'b', Modifiers: console.ModAlt}, ActionMoveWordLeft)
+	k.Bind(console.KeyEvent{Key: console.KeyRight, Modifiers: console.ModCtrl}, ActionMoveWordRight)
+	k.Bind(console.KeyEvent{Rune: 'f', Modifiers: console.ModAlt}, ActionMoveWordRight)
+	k.BindKey(console.KeyHome, ActionMoveToLineBegin)
+	k.BindKey(console.KeyCtrlA, ActionMoveToLineBegin)
+	k.BindKey(console.KeyEnd, ActionMoveToLineEnd)
+	k.BindKey(console.KeyCtrlE, ActionMoveToLineEnd)
+	k.BindKey(console.KeyBackspace, ActionDeleteLeft)
+	k.BindKey(console.KeyDelete, ActionDeleteRight)
+	k.BindKey(console.KeyEscape, ActionClearLine)
+	k.BindKey(console.KeyCtrlK, ActionKillToLineEnd)
+	k.BindKey(console.KeyCtrlU, ActionKillToLineBegin)
+	k.BindKey(console.KeyCtrlW, ActionKillWordLeft)
+	k.BindKey(console.KeyCtrlY, ActionYank)
+	k.Bind(console.KeyEvent{Rune: 'y', Modifiers: console.ModAlt}, ActionYankPop)
+	k.BindKey(console.KeyCtrlT, ActionTransposeChars)
+	k.BindKey(console.KeyUp, ActionHistoryPrev)
+	k.BindKey(console.KeyDown, ActionHistoryNext)
+	k.BindKey(console.KeyCtrlR, ActionHistorySearch)
+	k.BindKey(console.KeyTab, ActionComplete)
+	k.BindKey(console.KeyEnter, ActionAcceptLine)
+	k.BindKey(console.KeyCtrlC, ActionCancel)
+	k.BindKey(console.KeySpace, ActionSelfInsert)
+	return k
+}
+
+// Bind assigns an action to a key event. Existing bindings of the event are replaced.
+func (k *Keymap) Bind(event console.KeyEvent, action EditorAction) {
+	k.bindings[keymapKey(event)] = action
+}
+
+// BindKey assigns an action to a special key without modifiers.
+func (k *Keymap) BindKey(key console.Key, action EditorAction) {
+	k.Bind(console.KeyEvent{Key: key}, action)
+}
+
+// Unbind removes the binding of a key event.
+func (k *Keymap) Unbind(event console.KeyEvent) {
+	delete(k.bindings, keymapKey(event))
+}
+
+// RegisterWidget adds a custom action that can be bound to keys afterwards.
+func (k *Keymap) RegisterWidget(name EditorAction, widget Widget) {
+	k.widgets[name] = widget
+}
+
+// Action returns the action bound to a key event, falling back to the binding without modifiers except for Alt.
+func (k *Keymap) Action(event console.KeyEvent) (EditorAction, bool) {
+	if action, ok := k.bindings[keymapKey(event)]; ok {
+		return action, true
+	}
+	if event.Modifiers.Has(console.ModAlt) {
+		return "", false
+	}
+	if event.Key != 0 {
+		action, ok := k.bindings[console.KeyEvent{Key: event.Key}]
+		return action, ok
+	}
+	return ActionSelfInsert, true
+}
+
+// widget returns the custom action registered for name.
+func (k *Keymap) widget(name EditorAction) (Widget, bool) {
+	w, ok := k.widgets[name]
+	return w, ok
+}
+
+// keymapKey normalizes a key event for lookup in the bindings.
+func keymapKey(event console.KeyEvent) console.KeyEvent {
+	if event.Key == 0 {
+		return console.KeyEvent{Rune: event.Rune, Modifiers: event.Modifiers}
+	}
+	mods := event.Modifiers
+	if event.Key >= console.KeyCtrlA && event.Key <= console.KeyCtrlZ {
+		// control characters are reported with or without ctrl modifier depending on the input
+		mods &^= console.ModCtrl
+	}
+	return console.KeyEvent{Key: event.Key, Modifiers: mods}
+}
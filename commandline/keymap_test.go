@@ -0,0 +1,116 @@
+package commandline
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sbreitf1/go-console"
+	"github.com/sbreitf1/go-console/consoletest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeymapAction(t *testing.T) {
+	k := DefaultKeymap()
+
+	action, ok := k.Action(console.KeyEvent{Key: console.KeyCtrlA})
+	assert.True(t, ok)
+	assert.Equal(t, ActionMoveToLineBegin, action)
+	// control characters match with and without ctrl modifier
+	action, ok = k.Action(console.KeyEvent{Key: console.KeyCtrlA, Modifiers: console.ModCtrl})
+	assert.True(t, ok)
+	assert.Equal(t, ActionMoveToLineBegin, action)
+
+	action, ok = k.Action(console.KeyEvent{Key: console.KeyLeft, Modifiers: console.ModCtrl})
+	assert.True(t, ok)
+	assert.Equal(t, ActionMoveWordLeft, action)
+	// unbound modifiers fall back to the plain key
+	action, ok = k.Action(console.KeyEvent{Key: console.KeyUp, Modifiers: console.ModShift})
+	assert.True(t, ok)
+	assert.Equal(t, ActionHistoryPrev, action)
+
+	action, ok = k.Action(console.KeyEvent{Rune: 'x'})
+	assert.True(t, ok)
+	assert.Equal(t, ActionSelfInsert, action)
+	_, ok = k.Action(console.KeyEvent{Rune: 'x', Modifiers: console.ModAlt})
+	assert.False(t, ok)
+	_, ok = k.Action(console.KeyEvent{Key: console.KeyF1})
+	assert.False(t, ok)
+}
+
+func TestDefaultKeymapIsCopy(t *testing.T) {
+	k := DefaultKeymap()
+	k.Unbind(console.KeyEvent{Key: console.KeyEnter})
+
+	_, ok := k.Action(console.KeyEvent{Key: console.KeyEnter})
+	assert.False(t, ok)
+	action, ok := DefaultKeymap().Action(console.KeyEvent{Key: console.KeyEnter})
+	assert.True(t, ok)
+	assert.Equal(t, ActionAcceptLine, action)
+}
+
+func TestReadCommandKeymapRebind(t *testing.T) {
+	consoletest.WithMocks(func(input *consoletest.MockInput) {
+		k := DefaultKeymap()
+		k.BindKey(console.KeyCtrlP, ActionHistoryPrev)
+		k.Bind(console.KeyEvent{Rune: 'q'}, ActionAcceptLine)
+		k.Unbind(console.KeyEvent{Key: console.KeyUp})
+
+		input.PutKeys(console.KeyUp, console.KeyCtrlP)
+		input.PutString("!q")
+		cmd, err := ReadCommand("", &ReadCommandOptions{
+			GetHistoryEntry: func(index int) ([]string, bool) {
+				if index == 0 {
+					return []string{"foo"}, true
+				}
+				return nil, false
+			},
+			Keymap: k,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"foo!"}, cmd)
+		input.AssertBufferConsumed(t)
+	})
+}
+
+func TestReadCommandKeymapWidget(t *testing.T) {
+	consoletest.WithMocks(func(input *consoletest.MockInput) {
+		k := DefaultKeymap()
+		k.RegisterWidget("insert-timestamp", func(line LineBuffer) {
+			line.InsertAtCaret("2006-01-02")
+		})
+		k.RegisterWidget("upper-case", func(line LineBuffer) {
+			caret := line.Caret()
+			line.Replace(strings.ToUpper(line.String()))
+			line.SetCaret(caret)
+		})
+		k.BindKey(console.KeyCtrlT, "insert-timestamp")
+		k.Bind(console.KeyEvent{Rune: 'u', Modifiers: console.ModAlt}, "upper-case")
+
+		input.PutString("log ")
+		input.PutKeys(console.KeyCtrlT)
+		input.PutKeyEvents(console.KeyEvent{Rune: 'u', Modifiers: console.ModAlt})
+		input.PutKeys(console.KeyHome)
+		input.PutString("x\n")
+		cmd, err := ReadCommand("", &ReadCommandOptions{Keymap: k})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"xLOG", "2006-01-02"}, cmd)
+		input.AssertBufferConsumed(t)
+	})
+}
+
+func TestCommandLineEnvironmentKeymap(t *testing.T) {
+	c, input, _ := consoletest.NewMockConsole()
+	env := NewEnvironment()
+	env.Console = c
+	env.Keymap = DefaultKeymap()
+	env.Keymap.BindKey(console.KeyCtrlU, ActionClearLine)
+
+	input.PutString("foo bar")
+	input.PutKeys(console.KeyLeft, console.KeyCtrlU)
+	input.PutString("baz\n")
+	cmd, err := env.ReadCommand()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"baz"}, cmd)
+	input.AssertBufferConsumed(t)
+}
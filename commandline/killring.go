@@ -0,0 +1,80 @@
+package commandline
+
+const (
+	maxKillRingLen = 16
+)
+
+// killRing stores text removed by kill commands for later yanking.
+type killRing struct {
+	// entries holds the killed texts, latest last.
+	entries []string
+	// killed is true when the latest editing action has been a kill. Consecutive kills are merged into one entry.
+	killed bool
+	// yankIndex denotes the entry inserted by the latest yank.
+	yankIndex int
+	// yankStart and yankEnd denote the inserted text of the latest yank. yankEnd is -1 when the latest editing action has been no yank.
+	yankStart, yankEnd int
+}
+
+func newKillRing() *killRing {
+	return &killRing{yankEnd: -1}
+}
+
+// Kill saves text to the kill ring and merges it with the latest entry after consecutive kills.
+func (r *killRing) Kill(text string, prepend bool) {
+	if len(text) == 0 {
+		return
+	}
+
+	if r.killed && len(r.entries) > 0 {
+		last := len(r.entries) - 1
+		if prepend {
+			r.entries[last] = text + r.entries[last]
+		} else {
+			r.entries[last] += text
+		}
+	} else {
+		r.entries = append(r.entries, text)
+		if len(r.entries) > maxKillRingLen {
+			r.entries = r.entries[1:]
+		}
+	}
+	r.killed = true
+}
+
+// Yank inserts the latest killed text at the caret.
+func (r *killRing) Yank(line *lineEditor) bool {
+	if len(r.entries) == 0 {
+		return false
+	}
+	r.yankIndex = len(r.entries) - 1
+	r.insert(line)
+	return true
+}
+
+// YankPop replaces the text inserted by the previous yank with the next older entry of the kill ring.
+func (r *killRing) YankPop(line *lineEditor) bool {
+	if r.yankEnd < 0 || len(r.entries) == 0 {
+		return false
+	}
+	line.RemoveRange(r.yankStart, r.yankEnd)
+	r.yankIndex = (r.yankIndex - 1 + len(r.entries)) % len(r.entries)
+	r.insert(line)
+	return true
+}
+
+func (r *killRing) insert(line *lineEditor) {
+	r.yankStart = line.Caret()
+	line.InsertAtCaret(r.entries[r.yankIndex])
+	r.yankEnd = line.Caret()
+}
+
+// EndAction needs to be called after each editing action to reset the kill and yank chains.
+func (r *killRing) EndAction(killed, yanked bool) {
+	if !killed {
+		r.killed = false
+	}
+	if !yanked {
+		r.yankEnd = -1
+	}
+}
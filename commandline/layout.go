@@ -0,0 +1,63 @@
+package commandline
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sbreitf1/go-console"
+)
+
+const (
+	// clearToScreenEnd erases everything from the cursor to the end of the screen.
+	clearToScreenEnd = "\x1b[J"
+)
+
+// cursorPos denotes a terminal position relative to the beginning of the first row of an output region.
+type cursorPos struct {
+	row, col int
+}
+
+// advance returns the position after printing str at p on a terminal with the given width, or without line wrapping for width 0.
+func (p cursorPos) advance(str string, width int) cursorPos {
+	for _, cluster := range console.Graphemes(console.StripEscapeSequences(str)) {
+		w := console.StringWidth(cluster)
+		if width > 0 && p.col+w > width {
+			// terminal continues in the next row, wide characters are not split
+			p.row++
+			p.col = 0
+		}
+		p.col += w
+	}
+	return p
+}
+
+// before returns the position at which a cluster of the given width is printed when the cursor is at p.
+func (p cursorPos) before(clusterWidth, width int) cursorPos {
+	if width > 0 && (p.col >= width || p.col+clusterWidth > width) {
+		return cursorPos{p.row + 1, 0}
+	}
+	return p
+}
+
+// moveCursor writes escape sequences to sb that move the cursor from one position to another.
+func moveCursor(sb *strings.Builder, from, to cursorPos) {
+	if from.row > to.row {
+		fmt.Fprintf(sb, "\x1b[%dA", from.row-to.row)
+	} else if from.row < to.row {
+		fmt.Fprintf(sb, "\x1b[%dB", to.row-from.row)
+	}
+	// carriage return also resets a pending line wrap
+	sb.WriteString("\r")
+	if to.col > 0 {
+		fmt.Fprintf(sb, "\x1b[%dC", to.col)
+	}
+}
+
+// terminalWidth returns the current width of the console or 0 if it is unknown.
+func terminalWidth(c *console.Console) int {
+	width, _, err := c.GetSize()
+	if err != nil || width <= 0 {
+		return 0
+	}
+	return width
+}
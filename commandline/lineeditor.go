@@ -0,0 +1,499 @@
+package commandline
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sbreitf1/go-console"
+)
+
+var (
+	// hintStyle is used to display the inline suggestion after the line.
+	hintStyle = console.Style{Foreground: console.ColorBrightBlack}
+)
+
+// lineEditor holds the content of a single input line and keeps the terminal output in sync with it.
+type lineEditor struct {
+	console *console.Console
+	// prompt is printed in front of the line and may contain escape sequences.
+	prompt string
+	buffer []rune
+	caret  int
+	// hint denotes the suggested text that is displayed dimmed after the line.
+	hint []rune
+	// highlighter denotes the callback to style the line. highlightPrefix is passed in front of the line.
+	highlighter     Highlighter
+	highlightPrefix string
+	// styles holds the style of every rune of the line as computed by the last Refresh.
+	styles []console.Style
+	// depth denotes the color depth of the console as detected by the last Refresh.
+	depth console.ColorDepth
+
+	// shown denotes whether the prompt has been printed since the last Invalidate.
+	shown bool
+	// displayed, displayedHint and displayedCaret denote the line as currently visible on the terminal.
+	displayed       []rune
+	displayedHint   []rune
+	displayedCaret  int
+	displayedStyles []console.Style
+	// displayedWidth denotes the terminal width the displayed line has been laid out for. 0 if unknown.
+	displayedWidth int
+}
+
+func newLineEditor(c *console.Console) *lineEditor {
+	return &lineEditor{console: c, buffer: []rune{}}
+}
+
+// SetHighlighter sets the callback to style the line. The prefix is passed to the highlighter in front of the line.
+func (e *lineEditor) SetHighlighter(highlighter Highlighter, prefix string) {
+	e.highlighter = highlighter
+	e.highlightPrefix = prefix
+}
+
+// SetPrompt sets the text to print in front of the line. It is printed with the next Refresh after Invalidate.
+func (e *lineEditor) SetPrompt(prompt string) {
+	e.prompt = prompt
+}
+
+func (e *lineEditor) String() string {
+	return string(e.buffer)
+}
+
+// Len returns the number of runes in the line.
+func (e *lineEditor) Len() int {
+	return len(e.buffer)
+}
+
+// Caret returns the rune index of the caret. The caret is always placed between grapheme clusters by the editing methods.
+func (e *lineEditor) Caret() int {
+	return e.caret
+}
+
+// BeforeCaret returns the part of the line left of the caret.
+func (e *lineEditor) BeforeCaret() string {
+	return string(e.buffer[:e.caret])
+}
+
+func (e *lineEditor) MoveCaretLeft() bool {
+	if e.caret > 0 {
+		e.caret = clusterBefore(e.buffer, e.caret)
+		return true
+	}
+	return false
+}
+
+func (e *lineEditor) MoveCaretRight() bool {
+	if e.caret < len(e.buffer) {
+		e.caret = clusterAfter(e.buffer, e.caret)
+		return true
+	}
+	return false
+}
+
+// MoveCaretWordLeft moves the caret to the beginning of the current or previous word.
+func (e *lineEditor) MoveCaretWordLeft() bool {
+	if e.caret == 0 {
+		return false
+	}
+	for e.caret > 0 && !isWordRune(e.buffer[e.caret-1]) {
+		e.caret--
+	}
+	for e.caret > 0 && isWordRune(e.buffer[e.caret-1]) {
+		e.caret--
+	}
+	return true
+}
+
+// MoveCaretWordRight moves the caret to the end of the current or next word.
+func (e *lineEditor) MoveCaretWordRight() bool {
+	if e.caret >= len(e.buffer) {
+		return false
+	}
+	for e.caret < len(e.buffer) && !isWordRune(e.buffer[e.caret]) {
+		e.caret++
+	}
+	for e.caret < len(e.buffer) && isWordRune(e.buffer[e.caret]) {
+		e.caret++
+	}
+	return true
+}
+
+func (e *lineEditor) MoveCaretToLineBegin() bool {
+	e.caret = 0
+	return true
+}
+
+func (e *lineEditor) MoveCaretToLineEnd() bool {
+	e.caret = len(e.buffer)
+	return true
+}
+
+func (e *lineEditor) InsertAtCaret(str string) {
+	runes := []rune(str)
+	newBuffer := make([]rune, 0, len(e.buffer)+len(runes))
+	newBuffer = append(newBuffer, e.buffer[:e.caret]...)
+	newBuffer = append(newBuffer, runes...)
+	newBuffer = append(newBuffer, e.buffer[e.caret:]...)
+	e.buffer = newBuffer
+	e.caret += len(runes)
+}
+
+func (e *lineEditor) RemoveLeftOfCaret() bool {
+	if e.caret > 0 {
+		e.RemoveRange(clusterBefore(e.buffer, e.caret), e.caret)
+		return true
+	}
+	return false
+}
+
+func (e *lineEditor) RemoveRightOfCaret() bool {
+	if e.caret < len(e.buffer) {
+		caret := e.caret
+		e.RemoveRange(caret, clusterAfter(e.buffer, caret))
+		return true
+	}
+	return false
+}
+
+// SetCaret moves the caret to the given rune index.
+func (e *lineEditor) SetCaret(pos int) {
+	e.caret = max(0, min(pos, len(e.buffer)))
+}
+
+// RemoveRange removes the runes from start to end and returns the removed text. The caret is moved to start.
+func (e *lineEditor) RemoveRange(start, end int) string {
+	start = max(0, min(start, len(e.buffer)))
+	end = max(start, min(end, len(e.buffer)))
+	removed := string(e.buffer[start:end])
+	e.buffer = append(e.buffer[:start], e.buffer[end:]...)
+	e.caret = start
+	return removed
+}
+
+// KillToLineEnd removes and returns everything right of the caret.
+func (e *lineEditor) KillToLineEnd() string {
+	return e.RemoveRange(e.caret, len(e.buffer))
+}
+
+// KillToLineBegin removes and returns everything left of the caret.
+func (e *lineEditor) KillToLineBegin() string {
+	return e.RemoveRange(0, e.caret)
+}
+
+// KillWordLeft removes and returns the whitespace-delimited word left of the caret.
+func (e *lineEditor) KillWordLeft() string {
+	start := e.caret
+	for start > 0 && unicode.IsSpace(e.buffer[start-1]) {
+		start--
+	}
+	for start > 0 && !unicode.IsSpace(e.buffer[start-1]) {
+		start--
+	}
+	return e.RemoveRange(start, e.caret)
+}
+
+// TransposeChars swaps the grapheme cluster left of the caret with the one at the caret and moves the caret right.
+func (e *lineEditor) TransposeChars() bool {
+	caret := e.caret
+	if caret == len(e.buffer) {
+		caret = clusterBefore(e.buffer, caret)
+	}
+	if caret == 0 {
+		return false
+	}
+	start, end := clusterBefore(e.buffer, caret), clusterAfter(e.buffer, caret)
+	swapped := append(append([]rune{}, e.buffer[caret:end]...), e.buffer[start:caret]...)
+	copy(e.buffer[start:end], swapped)
+	e.caret = end
+	return true
+}
+
+// SetHint sets the suggested text to display after the line.
+func (e *lineEditor) SetHint(hint string) {
+	e.hint = []rune(hint)
+}
+
+// AcceptHint inserts the suggested text or only its next word if the caret is at the end of the line.
+func (e *lineEditor) AcceptHint(word bool) bool {
+	if len(e.hint) == 0 || e.caret < len(e.buffer) {
+		return false
+	}
+	n := len(e.hint)
+	if word {
+		n = 0
+		for n < len(e.hint) && !isWordRune(e.hint[n]) {
+			n++
+		}
+		for n < len(e.hint) && isWordRune(e.hint[n]) {
+			n++
+		}
+	}
+	e.InsertAtCaret(string(e.hint[:n]))
+	e.hint = e.hint[n:]
+	return true
+}
+
+// Replace sets the whole line content and moves the caret to the end of the line.
+func (e *lineEditor) Replace(str string) {
+	e.buffer = []rune(str)
+	e.caret = len(e.buffer)
+}
+
+// Clear removes all content from the line.
+func (e *lineEditor) Clear() {
+	e.Replace("")
+}
+
+// Invalidate forgets the displayed line so the next Refresh prints prompt and line again, e.g. after other output.
+func (e *lineEditor) Invalidate() {
+	e.shown = false
+	e.displayed = nil
+	e.displayedHint = nil
+	e.displayedCaret = 0
+	e.displayedStyles = nil
+}
+
+// Erase removes prompt and line from screen and moves the cursor to the beginning of the prompt. The next Refresh will print them again.
+func (e *lineEditor) Erase() {
+	if e.shown {
+		var sb strings.Builder
+		moveCursor(&sb, e.displayedCaretPos(terminalWidth(e.console)), cursorPos{})
+		sb.WriteString(clearToScreenEnd)
+		e.console.Print(sb.String())
+	}
+	e.Invalidate()
+}
+
+// Finish removes the hint and moves the cursor behind the displayed line to print further output in the next row.
+func (e *lineEditor) Finish() {
+	e.SetHint("")
+	e.Refresh()
+	if e.displayedCaret == len(e.displayed) {
+		return
+	}
+
+	width := terminalWidth(e.console)
+	if width > 0 && !e.fitsInRow(e.displayed, e.displayedHint, width) {
+		var sb strings.Builder
+		moveCursor(&sb, e.displayedCaretPos(width), e.endPos(width))
+		e.console.Print(sb.String())
+	} else {
+		// moving right is done by printing the remaining runes again
+		e.console.Print(e.sprintStyled(e.displayed, e.displayedStyles, e.displayedCaret, len(e.displayed)))
+	}
+	e.displayedCaret = len(e.displayed)
+}
+
+// Leave finishes the line and moves the cursor to the beginning of the next row.
+func (e *lineEditor) Leave() {
+	e.Finish()
+	width := terminalWidth(e.console)
+	if width > 0 && (cursorPos{}).advance(e.prompt+string(e.displayed), width).col >= width {
+		// cursor has already been moved to the next row when the line filled the last column
+		return
+	}
+	e.console.Println()
+}
+
+// Refresh updates the terminal output to display prompt, current line and caret position.
+func (e *lineEditor) Refresh() {
+	e.depth = e.console.ColorDepth()
+	e.styles = e.styles[:0]
+	if e.highlighter != nil && e.depth != console.ColorDepthNone {
+		e.styles = runeStyles(e.buffer, e.highlighter(e.highlightPrefix+string(e.buffer)), len(e.highlightPrefix))
+	}
+
+	width := terminalWidth(e.console)
+	inRow := width == 0 || (!e.shown || width == e.displayedWidth && e.fitsInRow(e.displayed, e.displayedHint, width)) && e.fitsInRow(e.buffer, e.hint, width)
+	if inRow {
+		if !e.shown {
+			e.console.Print(e.prompt)
+			e.shown = true
+		}
+		e.refreshInRow()
+	} else {
+		e.redraw(width)
+	}
+
+	e.displayed = append(e.displayed[:0], e.buffer...)
+	e.displayedHint = append(e.displayedHint[:0], e.hint...)
+	e.displayedCaret = e.caret
+	e.displayedStyles = append(e.displayedStyles[:0], e.styles...)
+	e.displayedWidth = width
+}
+
+// fitsInRow returns true if prompt, line and hint fit into a single row of the given width.
+func (e *lineEditor) fitsInRow(line, hint []rune, width int) bool {
+	return console.StringWidth(e.prompt)+runesWidth(line)+runesWidth(hint) < width
+}
+
+// caretPos returns the terminal position of the caret in front of the rune at index caret of line.
+func (e *lineEditor) caretPos(line []rune, caret, width int) cursorPos {
+	pos := cursorPos{}.advance(e.prompt+string(line[:caret]), width)
+	return pos.before(runesWidth(line[caret:clusterAfter(line, caret)]), width)
+}
+
+// displayedCaretPos returns the current position of the cursor for the current terminal width.
+func (e *lineEditor) displayedCaretPos(width int) cursorPos {
+	return e.caretPos(e.displayed, e.displayedCaret, width)
+}
+
+// endPos returns the position behind the displayed line and hint.
+func (e *lineEditor) endPos(width int) cursorPos {
+	end := cursorPos{}.advance(e.prompt+string(e.displayed)+string(e.displayedHint), width)
+	if width > 0 && end.col >= width {
+		return cursorPos{end.row + 1, 0}
+	}
+	return end
+}
+
+// redraw prints prompt, line and hint from the beginning of the prompt, which is required to cross row boundaries.
+func (e *lineEditor) redraw(width int) {
+	var sb strings.Builder
+	if e.shown {
+		moveCursor(&sb, e.displayedCaretPos(width), cursorPos{})
+		sb.WriteString(clearToScreenEnd)
+	}
+	e.shown = true
+
+	sb.WriteString(e.prompt)
+	sb.WriteString(e.sprintStyled(e.buffer, e.styles, 0, len(e.buffer)))
+	if len(e.hint) > 0 {
+		sb.WriteString(hintStyle.Sprint(e.depth, string(e.hint)))
+	}
+
+	end := cursorPos{}.advance(e.prompt+string(e.buffer)+string(e.hint), width)
+	if end.col >= width {
+		// resolve pending line wrap to have a well-defined cursor position
+		sb.WriteString(" \r")
+		end = cursorPos{end.row + 1, 0}
+	}
+	moveCursor(&sb, end, e.caretPos(e.buffer, e.caret, width))
+	e.console.Print(sb.String())
+}
+
+// refreshInRow updates a line that fits into a single row in place using backspaces.
+func (e *lineEditor) refreshInRow() {
+	// everything before the first changed rune can stay on screen
+	common := 0
+	for common < len(e.buffer) && common < len(e.displayed) && e.buffer[common] == e.displayed[common] && styleAt(e.styles, common) == styleAt(e.displayedStyles, common) {
+		common++
+	}
+	changed := common < len(e.buffer) || common < len(e.displayed) || string(e.hint) != string(e.displayedHint)
+	// grapheme clusters are always redrawn as a whole, e.g. when a combining mark has been appended
+	common = min(clusterStart(e.buffer, common), clusterStart(e.displayed, common))
+
+	var sb strings.Builder
+	pos := e.displayedCaret
+	start := e.caret
+	if changed && common < start {
+		start = common
+	}
+
+	// move cursor to start of the region to update
+	if start < pos {
+		sb.WriteString(strings.Repeat("\b", runesWidth(e.displayed[start:pos])))
+	} else if start > pos {
+		// moving right is done by printing the unchanged runes again
+		sb.WriteString(e.sprintStyled(e.displayed, e.displayedStyles, pos, start))
+	}
+
+	if changed {
+		sb.WriteString(e.sprintStyled(e.buffer, e.styles, start, len(e.buffer)))
+		if len(e.hint) > 0 {
+			sb.WriteString(hintStyle.Sprint(e.depth, string(e.hint)))
+		}
+		// all positions are measured in terminal columns from here
+		lineWidth := runesWidth(e.buffer) + runesWidth(e.hint)
+		if oldWidth := runesWidth(e.displayed) + runesWidth(e.displayedHint); oldWidth > lineWidth {
+			// overwrite remainder of old line
+			erase := oldWidth - lineWidth
+			sb.WriteString(strings.Repeat(" ", erase))
+			sb.WriteString(strings.Repeat("\b", erase))
+		}
+		sb.WriteString(strings.Repeat("\b", lineWidth-runesWidth(e.buffer[:e.caret])))
+	}
+
+	if sb.Len() > 0 {
+		e.console.Print(sb.String())
+	}
+}
+
+// sprintStyled returns line[from:to] with the escape sequences of styles. Runes without style are printed as they are.
+func (e *lineEditor) sprintStyled(line []rune, styles []console.Style, from, to int) string {
+	if len(styles) == 0 {
+		return string(line[from:to])
+	}
+
+	var sb strings.Builder
+	for from < to {
+		// print consecutive runes with same style at once
+		end := from + 1
+		for end < to && styles[end] == styles[from] {
+			end++
+		}
+		sb.WriteString(styles[from].Sprint(e.depth, string(line[from:end])))
+		from = end
+	}
+	return sb.String()
+}
+
+// styleAt returns the style at index i or an empty style if there are no styles.
+func styleAt(styles []console.Style, i int) console.Style {
+	if i < len(styles) {
+		return styles[i]
+	}
+	return console.Style{}
+}
+
+// runesWidth returns the number of terminal columns needed to display runes.
+func runesWidth(runes []rune) int {
+	return console.StringWidth(string(runes))
+}
+
+// clusterBoundaries returns the rune indices at which grapheme clusters start, followed by len(runes).
+func clusterBoundaries(runes []rune) []int {
+	boundaries := []int{0}
+	pos := 0
+	for _, cluster := range console.Graphemes(string(runes)) {
+		pos += utf8.RuneCountInString(cluster)
+		boundaries = append(boundaries, pos)
+	}
+	return boundaries
+}
+
+// clusterStart returns the start of the grapheme cluster containing the rune at pos.
+func clusterStart(runes []rune, pos int) int {
+	start := 0
+	for _, b := range clusterBoundaries(runes) {
+		if b > pos {
+			break
+		}
+		start = b
+	}
+	return start
+}
+
+// clusterBefore returns the start of the grapheme cluster left of pos.
+func clusterBefore(runes []rune, pos int) int {
+	if pos <= 0 {
+		return 0
+	}
+	return clusterStart(runes, pos-1)
+}
+
+// clusterAfter returns the end of the grapheme cluster right of pos.
+func clusterAfter(runes []rune, pos int) int {
+	for _, b := range clusterBoundaries(runes) {
+		if b > pos {
+			return b
+		}
+	}
+	return len(runes)
+}
+
+// isWordRune returns true for runes that are part of words for word-wise caret movement.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
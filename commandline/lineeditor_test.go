@@ -0,0 +1,358 @@
+package commandline
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sbreitf1/go-console/consoletest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineEditorInsert(t *testing.T) {
+	e := newLineEditor(nil)
+	e.InsertAtCaret("fooar")
+	assert.Equal(t, 5, e.Caret())
+	e.MoveCaretLeft()
+	e.MoveCaretLeft()
+	e.InsertAtCaret("b")
+	assert.Equal(t, "foobar", e.String())
+	assert.Equal(t, 4, e.Caret())
+	assert.Equal(t, "foob", e.BeforeCaret())
+}
+
+func TestLineEditorMoveCaret(t *testing.T) {
+	e := newLineEditor(nil)
+	assert.False(t, e.MoveCaretLeft())
+	assert.False(t, e.MoveCaretRight())
+	e.InsertAtCaret("äöü")
+	assert.False(t, e.MoveCaretRight())
+	assert.True(t, e.MoveCaretLeft())
+	assert.Equal(t, 2, e.Caret())
+	e.MoveCaretToLineBegin()
+	assert.Equal(t, 0, e.Caret())
+	e.MoveCaretToLineEnd()
+	assert.Equal(t, 3, e.Caret())
+}
+
+func TestLineEditorRemove(t *testing.T) {
+	e := newLineEditor(nil)
+	e.InsertAtCaret("foobar")
+	e.MoveCaretToLineBegin()
+	assert.False(t, e.RemoveLeftOfCaret())
+	assert.True(t, e.RemoveRightOfCaret())
+	e.MoveCaretRight()
+	assert.True(t, e.RemoveLeftOfCaret())
+	assert.Equal(t, "obar", e.String())
+	assert.Equal(t, 0, e.Caret())
+	e.MoveCaretToLineEnd()
+	assert.False(t, e.RemoveRightOfCaret())
+}
+
+func TestLineEditorKill(t *testing.T) {
+	e := newLineEditor(nil)
+	e.InsertAtCaret("echo  foo/bar  baz")
+	e.SetCaret(15)
+	assert.Equal(t, "foo/bar  ", e.KillWordLeft())
+	assert.Equal(t, "echo  baz", e.String())
+	assert.Equal(t, 6, e.Caret())
+	assert.Equal(t, "baz", e.KillToLineEnd())
+	assert.Equal(t, "echo  ", e.KillToLineBegin())
+	assert.Equal(t, "", e.String())
+	assert.Equal(t, "", e.KillWordLeft())
+}
+
+func TestLineEditorTransposeChars(t *testing.T) {
+	e := newLineEditor(nil)
+	e.InsertAtCaret("abcd")
+	e.SetCaret(0)
+	assert.False(t, e.TransposeChars())
+	e.SetCaret(1)
+	assert.True(t, e.TransposeChars())
+	assert.Equal(t, "bacd", e.String())
+	assert.Equal(t, 2, e.Caret())
+	e.MoveCaretToLineEnd()
+	assert.True(t, e.TransposeChars())
+	assert.Equal(t, "badc", e.String())
+	assert.Equal(t, 4, e.Caret())
+}
+
+func TestLineEditorGraphemeClusters(t *testing.T) {
+	e := newLineEditor(nil)
+	// decomposed e with acute accent, CJK, thumbs up with skin tone
+	e.InsertAtCaret("ae\u0301日\U0001F44D\U0001F3FD")
+	assert.Equal(t, 6, e.Caret())
+	assert.True(t, e.MoveCaretLeft())
+	assert.Equal(t, 4, e.Caret())
+	assert.True(t, e.MoveCaretLeft())
+	assert.True(t, e.MoveCaretLeft())
+	assert.Equal(t, 1, e.Caret())
+	assert.True(t, e.MoveCaretRight())
+	assert.Equal(t, 3, e.Caret())
+
+	assert.True(t, e.RemoveLeftOfCaret())
+	assert.Equal(t, "a日\U0001F44D\U0001F3FD", e.String())
+	assert.Equal(t, 1, e.Caret())
+	assert.True(t, e.TransposeChars())
+	assert.Equal(t, "日a\U0001F44D\U0001F3FD", e.String())
+	assert.Equal(t, 2, e.Caret())
+	assert.True(t, e.RemoveRightOfCaret())
+	assert.Equal(t, "日a", e.String())
+}
+
+func TestLineEditorRefreshWideCharacters(t *testing.T) {
+	c, _, output := consoletest.NewMockConsole()
+	e := newLineEditor(c)
+
+	e.InsertAtCaret("日本")
+	e.Refresh()
+	assert.Equal(t, "日本", output.String())
+
+	// wide characters take two columns
+	e.MoveCaretLeft()
+	e.Refresh()
+	assert.Equal(t, "日本\b\b", output.String())
+
+	e.RemoveLeftOfCaret()
+	e.Refresh()
+	assert.Equal(t, "日本\b\b\b\b本  \b\b\b\b", output.String())
+}
+
+func TestLineEditorRefreshCombiningMark(t *testing.T) {
+	c, _, output := consoletest.NewMockConsole()
+	e := newLineEditor(c)
+
+	e.InsertAtCaret("ae")
+	e.Refresh()
+	// combining mark is printed together with its base character
+	e.InsertAtCaret("\u0301")
+	e.Refresh()
+	assert.Equal(t, "ae\be\u0301", output.String())
+}
+
+func TestLineEditorRefreshWrappedLine(t *testing.T) {
+	c, _, output := consoletest.NewMockConsole()
+	output.Width = 10
+	term := newVirtualTerminal(output)
+	e := newLineEditor(c)
+	e.SetPrompt("> ")
+
+	e.Refresh()
+	e.InsertAtCaret("echo foo bar")
+	e.Refresh()
+	term.Update()
+	assert.Equal(t, []string{"> echo foo", " bar"}, term.Rows())
+	assert.Equal(t, cursorPos{1, 4}, term.Cursor())
+
+	// editing in the first row redraws the wrapped remainder
+	e.SetCaret(4)
+	e.RemoveLeftOfCaret()
+	e.Refresh()
+	term.Update()
+	assert.Equal(t, []string{"> ech foo", "bar"}, term.Rows())
+	assert.Equal(t, cursorPos{0, 5}, term.Cursor())
+
+	// shrinking the line back to a single row removes the second row
+	e.MoveCaretToLineEnd()
+	e.KillWordLeft()
+	e.Refresh()
+	term.Update()
+	assert.Equal(t, []string{"> ech foo", ""}, term.Rows())
+	assert.Equal(t, cursorPos{1, 0}, term.Cursor())
+
+	e.Leave()
+	term.Update()
+	assert.Equal(t, cursorPos{1, 0}, term.Cursor())
+}
+
+func TestLineEditorRefreshExactWidth(t *testing.T) {
+	c, _, output := consoletest.NewMockConsole()
+	output.Width = 10
+	term := newVirtualTerminal(output)
+	e := newLineEditor(c)
+	e.SetPrompt("> ")
+
+	// cursor is moved to the next row when the line fills the last column
+	e.InsertAtCaret("12345678")
+	e.Refresh()
+	term.Update()
+	assert.Equal(t, []string{"> 12345678", ""}, term.Rows())
+	assert.Equal(t, cursorPos{1, 0}, term.Cursor())
+
+	e.MoveCaretLeft()
+	e.Refresh()
+	term.Update()
+	assert.Equal(t, cursorPos{0, 9}, term.Cursor())
+
+	// no empty row is left behind the line
+	e.Leave()
+	term.Update()
+	assert.Equal(t, []string{"> 12345678", ""}, term.Rows())
+	assert.Equal(t, cursorPos{1, 0}, term.Cursor())
+}
+
+func TestLineEditorRefreshResize(t *testing.T) {
+	c, _, output := consoletest.NewMockConsole()
+	output.Width = 10
+	term := newVirtualTerminal(output)
+	e := newLineEditor(c)
+	e.SetPrompt("> ")
+
+	e.InsertAtCaret("echo foo bar")
+	e.SetCaret(2)
+	e.Refresh()
+	term.Update()
+	assert.Equal(t, []string{"> echo foo", " bar"}, term.Rows())
+
+	// line is laid out again for the new width
+	output.Width = 20
+	term.Resize()
+	e.Refresh()
+	term.Update()
+	assert.Equal(t, []string{"> echo foo bar"}, term.Rows())
+	assert.Equal(t, cursorPos{0, 4}, term.Cursor())
+
+	output.Width = 6
+	term.Resize()
+	e.InsertAtCaret("x")
+	e.Refresh()
+	term.Update()
+	assert.Equal(t, []string{"> ecxh", "o foo", "bar"}, term.Rows())
+	assert.Equal(t, cursorPos{0, 5}, term.Cursor())
+}
+
+// virtualTerminal interprets the output of the line editor like a terminal with automatic line wrapping.
+type virtualTerminal struct {
+	output  *consoletest.MockOutput
+	written int
+	width   int
+	rows    [][]rune
+	row     int
+	col     int
+	pending bool
+}
+
+func newVirtualTerminal(output *consoletest.MockOutput) *virtualTerminal {
+	return &virtualTerminal{output: output, width: output.Width, rows: [][]rune{{}}}
+}
+
+// Update interprets all output printed since the last call.
+func (t *virtualTerminal) Update() {
+	str := t.output.String()
+	runes := []rune(str[t.written:])
+	t.written = len(str)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; r {
+		case '\x1b':
+			// CSI with optional numeric parameter
+			n, j := 0, i+2
+			for ; j < len(runes) && runes[j] >= '0' && runes[j] <= '9'; j++ {
+				n = 10*n + int(runes[j]-'0')
+			}
+			n = max(n, 1)
+			t.pending = false
+			switch runes[j] {
+			case 'A':
+				t.row = max(t.row-n, 0)
+			case 'B':
+				t.row += n
+				t.ensureRow()
+			case 'C':
+				t.col = min(t.col+n, t.width-1)
+			case 'D':
+				t.col = max(t.col-n, 0)
+			case 'J':
+				t.rows = t.rows[:t.row+1]
+				t.truncate()
+			case 'K':
+				t.truncate()
+			}
+			i = j
+		case '\r':
+			t.col = 0
+			t.pending = false
+		case '\n':
+			t.row++
+			t.col = 0
+			t.pending = false
+			t.ensureRow()
+		case '\b':
+			t.col = max(t.col-1, 0)
+			t.pending = false
+		default:
+			if t.pending {
+				t.row++
+				t.col = 0
+				t.pending = false
+				t.ensureRow()
+			}
+			line := t.rows[t.row]
+			for len(line) <= t.col {
+				line = append(line, ' ')
+			}
+			line[t.col] = r
+			t.rows[t.row] = line
+			if t.col == t.width-1 {
+				t.pending = true
+			} else {
+				t.col++
+			}
+		}
+	}
+}
+
+func (t *virtualTerminal) ensureRow() {
+	for len(t.rows) <= t.row {
+		t.rows = append(t.rows, []rune{})
+	}
+}
+
+func (t *virtualTerminal) truncate() {
+	if len(t.rows[t.row]) > t.col {
+		t.rows[t.row] = t.rows[t.row][:t.col]
+	}
+}
+
+// Resize reflows the content to the current output width like a terminal does for a single wrapped line.
+func (t *virtualTerminal) Resize() {
+	width := t.output.Width
+	var cells []rune
+	for i, line := range t.rows {
+		cells = append(cells, line...)
+		if i < len(t.rows)-1 {
+			for j := len(line); j < t.width; j++ {
+				cells = append(cells, ' ')
+			}
+		}
+	}
+	offset := t.row*t.width + t.col
+	if t.pending {
+		offset++
+	}
+
+	t.width = width
+	t.rows = [][]rune{}
+	for len(cells) > width {
+		t.rows = append(t.rows, cells[:width])
+		cells = cells[width:]
+	}
+	t.rows = append(t.rows, cells)
+	t.row, t.col, t.pending = offset/width, offset%width, false
+	t.ensureRow()
+}
+
+func (t *virtualTerminal) Rows() []string {
+	rows := make([]string, len(t.rows))
+	for i, line := range t.rows {
+		// blank cells cannot be distinguished from spaces
+		rows[i] = strings.TrimRight(string(line), " ")
+	}
+	return rows
+}
+
+func (t *virtualTerminal) Cursor() cursorPos {
+	if t.pending {
+		return cursorPos{t.row, t.width}
+	}
+	return cursorPos{t.row, t.col}
+}
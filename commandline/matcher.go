@@ -0,0 +1,135 @@
+package commandline
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// CompletionMatcher decides whether a completion option matches the typed command part. Higher scores are displayed first.
+type CompletionMatcher func(replacement, input string) (score int, ok bool)
+
+// MatchPrefix only accepts options that start with the input. This is the default matcher.
+func MatchPrefix(replacement, input string) (int, bool) {
+	return 0, strings.HasPrefix(replacement, input)
+}
+
+// MatchPrefixIgnoreCase accepts options that start with the input in any case. Options with matching case are ranked first.
+func MatchPrefixIgnoreCase(replacement, input string) (int, bool) {
+	if strings.HasPrefix(replacement, input) {
+		return 1, true
+	}
+	return 0, hasPrefixFold(replacement, input)
+}
+
+// MatchSubstring accepts options that contain the input. Options with an earlier occurrence are ranked first.
+func MatchSubstring(replacement, input string) (int, bool) {
+	pos := strings.Index(replacement, input)
+	return -pos, pos >= 0
+}
+
+// MatchFuzzy accepts options that contain all characters of the input in the same order regardless of case, e.g. "gco" matches "git-checkout".
+func MatchFuzzy(replacement, input string) (int, bool) {
+	runes := []rune(replacement)
+	score := 0
+	pos := 0
+	last := -1
+	for _, r := range input {
+		// find next occurrence of the character
+		for pos < len(runes) && !equalFoldRune(runes[pos], r) {
+			pos++
+		}
+		if pos >= len(runes) {
+			return 0, false
+		}
+
+		switch {
+		case pos == 0:
+			score += 8
+		case last >= 0 && pos == last+1:
+			score += 5
+		case isWordBoundary(runes, pos):
+			score += 3
+		}
+		if runes[pos] == r {
+			score++
+		}
+		if last >= 0 {
+			// penalize skipped characters
+			score -= pos - last - 1
+		}
+		last = pos
+		pos++
+	}
+	return score, true
+}
+
+// isWordBoundary returns true if the rune at pos starts a new word, e.g. after a separator or at a lower to upper case transition.
+func isWordBoundary(runes []rune, pos int) bool {
+	prev := runes[pos-1]
+	if !unicode.IsLetter(prev) && !unicode.IsDigit(prev) {
+		return true
+	}
+	return unicode.IsLower(prev) && unicode.IsUpper(runes[pos])
+}
+
+func equalFoldRune(a, b rune) bool {
+	return a == b || unicode.ToLower(a) == unicode.ToLower(b)
+}
+
+// hasPrefixFold is like strings.HasPrefix, but ignores case.
+func hasPrefixFold(str, prefix string) bool {
+	strRunes, prefixRunes := []rune(str), []rune(prefix)
+	if len(prefixRunes) > len(strRunes) {
+		return false
+	}
+	for i, r := range prefixRunes {
+		if !equalFoldRune(strRunes[i], r) {
+			return false
+		}
+	}
+	return true
+}
+
+// filterOptions returns all options accepted by matcher ranked by score within their group. A nil matcher denotes MatchPrefix.
+func filterOptions(options []CompletionOption, input string, matcher CompletionMatcher) []CompletionOption {
+	if options == nil {
+		return nil
+	}
+	if matcher == nil {
+		matcher = MatchPrefix
+	}
+
+	type match struct {
+		option CompletionOption
+		group  int
+		score  int
+	}
+	groups := make(map[string]int)
+	matches := make([]match, 0)
+	for _, c := range options {
+		group, exists := groups[optionGroup(c)]
+		if !exists {
+			group = len(groups)
+			groups[optionGroup(c)] = group
+		}
+		if score, ok := matcher(c.Replacement(), input); ok {
+			matches = append(matches, match{c, group, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].group != matches[j].group {
+			return matches[i].group < matches[j].group
+		}
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].option.String() < matches[j].option.String()
+	})
+
+	filtered := make([]CompletionOption, len(matches))
+	for i, m := range matches {
+		filtered[i] = m.option
+	}
+	return filtered
+}
@@ -0,0 +1,90 @@
+package commandline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sbreitf1/go-console"
+	"github.com/sbreitf1/go-console/consoletest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchers(t *testing.T) {
+	_, ok := MatchPrefix("Foo", "fo")
+	assert.False(t, ok)
+
+	score, ok := MatchPrefixIgnoreCase("Foo", "fo")
+	assert.True(t, ok)
+	exactScore, _ := MatchPrefixIgnoreCase("foo", "fo")
+	assert.Greater(t, exactScore, score)
+	_, ok = MatchPrefixIgnoreCase("Foo", "oo")
+	assert.False(t, ok)
+
+	_, ok = MatchSubstring("config.yaml", "yaml")
+	assert.True(t, ok)
+	_, ok = MatchSubstring("config.yaml", "YAML")
+	assert.False(t, ok)
+
+	_, ok = MatchFuzzy("git-checkout", "gco")
+	assert.True(t, ok)
+	_, ok = MatchFuzzy("git-checkout", "gcx")
+	assert.False(t, ok)
+	_, ok = MatchFuzzy("git-checkout", "ocg")
+	assert.False(t, ok)
+}
+
+func TestMatchFuzzyRanking(t *testing.T) {
+	options := PrepareCompletionOptions([]string{"go-checkout", "git-commit", "git-checkout", "gco"}, false)
+	filtered := filterOptions(options, "gco", MatchFuzzy)
+	// exact match first, consecutive characters before fewer skipped characters
+	assert.Equal(t, []string{"gco", "git-commit", "go-checkout", "git-checkout"}, optionStrings(filtered))
+
+	filtered = filterOptions(options, "gc", MatchPrefix)
+	assert.Equal(t, []string{"gco"}, optionStrings(filtered))
+	filtered = filterOptions(options, "git", nil)
+	assert.Equal(t, []string{"git-checkout", "git-commit"}, optionStrings(filtered))
+}
+
+func TestCompletionPartStart(t *testing.T) {
+	assert.Equal(t, 0, completionPartStart("", ""))
+	assert.Equal(t, 5, completionPartStart("", "echo foo"))
+	assert.Equal(t, 9, completionPartStart("", "echo foo "))
+	assert.Equal(t, 5, completionPartStart("", `echo "ä b`))
+	// part started in a previous line
+	assert.Equal(t, 0, completionPartStart("echo 'foo\n", "bar"))
+}
+
+func TestReadCommandCompletionMatcher(t *testing.T) {
+	// previous tests must not trigger double-tab
+	lastTabPress = time.Unix(0, 0)
+	c, input, _ := consoletest.NewMockConsole()
+	completion := func(cmd []string, index int) []CompletionOption {
+		return PrepareCompletionOptions([]string{"git-checkout", "Git-Stash", "git-status"}, false)
+	}
+
+	// non-prefix match replaces the whole command part
+	input.PutString("echo gco")
+	input.PutKeys(console.KeyTab)
+	input.PutString("\n")
+	cmd, err := ReadCommand("", &ReadCommandOptions{Console: c, GetCompletionOptions: completion, CompletionMatcher: MatchFuzzy})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"echo", "git-checkout"}, cmd)
+
+	// common prefix is completed regardless of case
+	input.PutString("echo GIT-ST")
+	input.PutKeys(console.KeyTab)
+	input.PutString("\n")
+	cmd, err = ReadCommand("", &ReadCommandOptions{Console: c, GetCompletionOptions: completion, CompletionMatcher: MatchPrefixIgnoreCase})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"echo", "Git-Sta"}, cmd)
+	input.AssertBufferConsumed(t)
+}
+
+func optionStrings(options []CompletionOption) []string {
+	strs := make([]string, len(options))
+	for i, option := range options {
+		strs[i] = option.String()
+	}
+	return strs
+}
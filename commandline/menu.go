@@ -0,0 +1,227 @@
+package commandline
+
+import (
+	"context"
+	"strings"
+
+	"github.com/sbreitf1/go-console"
+)
+
+const (
+	// menuSpaceLen denotes the number of spaces between the columns of the completion menu.
+	menuSpaceLen = 2
+)
+
+var (
+	// menuSelectionStyle is used to highlight the selected option in menu completion.
+	menuSelectionStyle = console.Style{Foreground: console.ColorBlack, Background: console.ColorWhite}
+)
+
+// menuCompletion implements the selection of a completion option from a grid below the command line.
+type menuCompletion struct {
+	console *console.Console
+	options []CompletionOption
+	// prefix denotes the command part that is completed by the options. It begins at partStart in the line.
+	prefix    string
+	partStart int
+
+	// selected denotes the index of the selected option or -1 before the first selection.
+	selected int
+	// top denotes the first visible row of the grid if not all rows fit on screen.
+	top int
+	// gridShown denotes whether the grid is visible below the line.
+	gridShown bool
+
+	originalLine  string
+	originalCaret int
+}
+
+// run displays the options until an option is accepted or the menu is cancelled. ok is true if the returned key needs to be processed.
+func (m *menuCompletion) run(ctx context.Context, line *lineEditor) (event console.KeyEvent, ok bool, err error) {
+	m.originalLine, m.originalCaret = line.String(), line.Caret()
+	m.selected = -1
+	line.SetHint("")
+	m.render(line)
+
+	for {
+		event, err := m.console.ReadKeyEventContext(ctx)
+		if err != nil {
+			m.clear(line)
+			return console.KeyEvent{}, false, err
+		}
+
+		switch {
+		case event.Key == console.KeyTab && event.Modifiers.Has(console.ModShift), event.Key == console.KeyLeft:
+			m.move(-1)
+		case event.Key == console.KeyTab, event.Key == console.KeyRight:
+			m.move(1)
+		case event.Key == console.KeyUp:
+			m.move(-m.columns(terminalWidth(m.console)))
+		case event.Key == console.KeyDown:
+			m.move(m.columns(terminalWidth(m.console)))
+
+		case event.Key == console.KeyCtrlC:
+			m.clear(line)
+			return console.KeyEvent{}, false, ErrCtrlC()
+
+		case event.Key == console.KeyEscape, event.Key == console.KeyCtrlG:
+			m.clear(line)
+			m.restore(line)
+			return console.KeyEvent{}, false, nil
+
+		case event.Key == console.KeyEnter:
+			m.clear(line)
+			if m.selected >= 0 && !m.options[m.selected].IsPartial() {
+				line.InsertAtCaret(" ")
+			}
+			return console.KeyEvent{}, false, nil
+
+		default:
+			// other keys keep the selected option and are processed on the line
+			m.clear(line)
+			return event, true, nil
+		}
+
+		m.apply(line)
+		m.render(line)
+	}
+}
+
+// move changes the selection by delta options. The first movement selects the first or last option.
+func (m *menuCompletion) move(delta int) {
+	if m.selected < 0 {
+		if delta > 0 {
+			m.selected = 0
+		} else {
+			m.selected = len(m.options) - 1
+		}
+		return
+	}
+
+	next := m.selected + delta
+	if delta == 1 || delta == -1 {
+		// Tab and Shift+Tab cycle through all options
+		next = (next + len(m.options)) % len(m.options)
+	}
+	if next >= 0 && next < len(m.options) {
+		m.selected = next
+	}
+}
+
+// apply writes the selected option to line.
+func (m *menuCompletion) apply(line *lineEditor) {
+	m.restore(line)
+	if m.selected >= 0 {
+		insertCompletion(line, m.partStart, m.prefix, m.options[m.selected].Replacement())
+	}
+}
+
+// restore resets line to the input before the menu has been opened.
+func (m *menuCompletion) restore(line *lineEditor) {
+	line.Replace(m.originalLine)
+	line.SetCaret(m.originalCaret)
+}
+
+// columnWidth returns the number of cells for every option in the grid.
+func (m *menuCompletion) columnWidth(width int) int {
+	maxLen := 0
+	for _, option := range m.options {
+		maxLen = max(maxLen, console.StringWidth(option.String()))
+	}
+	// the last column is never filled to avoid line wraps
+	return max(min(maxLen, width-1), 1)
+}
+
+// columns returns the number of options displayed in a single row of the grid.
+func (m *menuCompletion) columns(width int) int {
+	return max((width-1+menuSpaceLen)/(m.columnWidth(width)+menuSpaceLen), 1)
+}
+
+// render updates the line and prints the grid below it. The selected option is highlighted and kept visible.
+func (m *menuCompletion) render(line *lineEditor) {
+	line.Refresh()
+
+	width, height, _ := m.console.GetSize()
+	colWidth := m.columnWidth(width)
+	columns := m.columns(width)
+	rows := (len(m.options) + columns - 1) / columns
+
+	caret := line.displayedCaretPos(width)
+	end := line.endPos(width)
+	// the grid must fit on screen together with the line
+	visibleRows := rows
+	if height > 0 {
+		visibleRows = max(min(rows, height-end.row-1), 1)
+	}
+	if m.selected >= 0 {
+		if row := m.selected / columns; row < m.top {
+			m.top = row
+		} else if row >= m.top+visibleRows {
+			m.top = row - visibleRows + 1
+		}
+	}
+	m.top = min(m.top, rows-visibleRows)
+
+	var sb strings.Builder
+	moveCursor(&sb, caret, cursorPos{end.row, 0})
+	sb.WriteString("\n")
+	sb.WriteString(clearToScreenEnd)
+	for row := m.top; row < m.top+visibleRows; row++ {
+		if row > m.top {
+			sb.WriteString("\n")
+		}
+		for col := 0; col < columns; col++ {
+			index := row*columns + col
+			if index >= len(m.options) {
+				break
+			}
+			if col > 0 {
+				sb.WriteString(strings.Repeat(" ", menuSpaceLen))
+			}
+			label := truncateWidth(m.options[index].String(), colWidth)
+			label += strings.Repeat(" ", colWidth-console.StringWidth(label))
+			if index == m.selected {
+				label = m.console.SprintStyled(menuSelectionStyle, label)
+			}
+			sb.WriteString(label)
+		}
+	}
+	moveCursor(&sb, cursorPos{end.row + visibleRows, 0}, caret)
+	m.console.Print(sb.String())
+	m.gridShown = true
+}
+
+// clear removes the grid from screen and keeps the cursor at the caret.
+func (m *menuCompletion) clear(line *lineEditor) {
+	if !m.gridShown {
+		return
+	}
+	width := terminalWidth(m.console)
+	caret := line.displayedCaretPos(width)
+	grid := cursorPos{line.endPos(width).row + 1, 0}
+
+	var sb strings.Builder
+	moveCursor(&sb, caret, grid)
+	sb.WriteString(clearToScreenEnd)
+	moveCursor(&sb, grid, caret)
+	m.console.Print(sb.String())
+	m.gridShown = false
+}
+
+// truncateWidth shortens str to at most width terminal columns without splitting grapheme clusters.
+func truncateWidth(str string, width int) string {
+	if console.StringWidth(str) <= width {
+		return str
+	}
+	var sb strings.Builder
+	used := 0
+	for _, cluster := range console.Graphemes(str) {
+		w := console.StringWidth(cluster)
+		if used+w > width {
+			break
+		}
+		sb.WriteString(cluster)
+		used += w
+	}
+	return sb.String()
+}
@@ -0,0 +1,126 @@
+package commandline
+
+import (
+	"testing"
+
+	"github.com/sbreitf1/go-console"
+	"github.com/sbreitf1/go-console/consoletest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMenuCompletion(t *testing.T) {
+	c, input, output := consoletest.NewMockConsole()
+	term := newVirtualTerminal(output)
+	opts := &ReadCommandOptions{
+		Console:              c,
+		GetCompletionOptions: newMenuTestCompletion("foo", "far", "fab"),
+		MenuCompletion:       true,
+	}
+
+	// select with Tab and Shift+Tab, Enter accepts the option
+	input.PutString("f")
+	input.PutKeys(console.KeyTab, console.KeyTab, console.KeyTab)
+	input.PutKeyEvents(console.KeyEvent{Key: console.KeyTab, Modifiers: console.ModShift})
+	input.PutKeys(console.KeyEnter)
+	input.PutString("x\n")
+	cmd, err := ReadCommand("", opts)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"fab", "x"}, cmd)
+	// grid has been erased without scrolling
+	term.Update()
+	assert.Equal(t, []string{"> fab x", ""}, term.Rows())
+	assert.Contains(t, output.String(), "fab  far  foo")
+
+	// Esc restores the typed text
+	input.PutString("f")
+	input.PutKeys(console.KeyTab, console.KeyDown, console.KeyDown, console.KeyEscape)
+	input.PutString("\n")
+	cmd, err = ReadCommand("", opts)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"f"}, cmd)
+
+	// other keys keep the selection and are processed on the line
+	input.PutString("f")
+	input.PutKeys(console.KeyTab, console.KeyLeft)
+	input.PutString("!\n")
+	cmd, err = ReadCommand("", opts)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"foo!"}, cmd)
+	input.AssertBufferConsumed(t)
+}
+
+func TestMenuCompletionRender(t *testing.T) {
+	c, _, output := consoletest.NewMockConsole()
+	term := newVirtualTerminal(output)
+	line := newLineEditor(c)
+	line.SetPrompt("> ")
+	line.InsertAtCaret("f")
+
+	menu := &menuCompletion{console: c, options: []CompletionOption{
+		NewCompletionOption("fab", false),
+		NewCompletionOption("far", false),
+		NewCompletionOption("foo", false),
+	}, prefix: "f", selected: -1}
+	menu.originalLine, menu.originalCaret = "f", 1
+	menu.render(line)
+	term.Update()
+	assert.Equal(t, []string{"> f", "fab  far  foo"}, term.Rows())
+	assert.Equal(t, cursorPos{0, 3}, term.Cursor())
+
+	menu.move(1)
+	menu.move(1)
+	menu.apply(line)
+	menu.render(line)
+	term.Update()
+	assert.Equal(t, []string{"> far", "fab  far  foo"}, term.Rows())
+	assert.Equal(t, cursorPos{0, 5}, term.Cursor())
+
+	// grid is erased in place
+	menu.clear(line)
+	term.Update()
+	assert.Equal(t, []string{"> far", ""}, term.Rows())
+	assert.Equal(t, cursorPos{0, 5}, term.Cursor())
+}
+
+func TestMenuCompletionScroll(t *testing.T) {
+	c, _, output := consoletest.NewMockConsole()
+	output.Width = 10
+	output.Height = 3
+	term := newVirtualTerminal(output)
+	line := newLineEditor(c)
+	line.SetPrompt("> ")
+
+	options := make([]CompletionOption, 0)
+	for _, str := range []string{"o0", "o1", "o2", "o3", "o4", "o5", "o6", "o7", "o8", "o9"} {
+		options = append(options, NewCompletionOption(str, false))
+	}
+	menu := &menuCompletion{console: c, options: options, selected: -1}
+	menu.render(line)
+	term.Update()
+	assert.Equal(t, []string{">", "o0  o1", "o2  o3"}, term.Rows())
+
+	// rows are scrolled to keep the selection visible
+	menu.selected = 6
+	menu.apply(line)
+	menu.render(line)
+	term.Update()
+	assert.Equal(t, []string{"> o6", "o4  o5", "o6  o7"}, term.Rows())
+	assert.Equal(t, cursorPos{0, 4}, term.Cursor())
+
+	// Down does not leave the grid
+	menu.move(2)
+	menu.move(2)
+	menu.move(2)
+	assert.Equal(t, 8, menu.selected)
+}
+
+func newMenuTestCompletion(options ...string) CommandCompletionHandler {
+	return func(cmd []string, index int) []CompletionOption {
+		result := make([]CompletionOption, 0, len(options))
+		for _, option := range options {
+			result = append(result, NewCompletionOption(option, false))
+		}
+		return result
+	}
+}
@@ -1,6 +1,9 @@
 package commandline
 
 import (
+	"context"
+	"strings"
+
 	"github.com/sbreitf1/go-console"
 )
 
@@ -8,49 +11,86 @@ import (
 type LineHistory interface {
 	Put(string)
 	GetHistoryEntry(int) (string, bool)
+	// Search returns the latest entry at index start or older that contains query.
+	Search(query string, start int) (int, string, bool)
 }
 
 type memoryLineHistory struct {
-	history    []string
-	count, pos int
+	maxCount int
+	history  []string
+	opts     HistoryOptions
 }
 
-// NewLineHistory returns a new line history for maxCount entries.
+// NewLineHistory returns a new line history for maxCount entries. Duplicates are kept, use NewLineHistoryWithOptions to erase them.
 func NewLineHistory(maxCount int) LineHistory {
-	return &memoryLineHistory{make([]string, maxCount), 0, 0}
+	return NewLineHistoryWithOptions(maxCount, HistoryOptions{})
+}
+
+// NewLineHistoryWithOptions returns a new line history for maxCount entries that only saves lines accepted by opts.
+func NewLineHistoryWithOptions(maxCount int, opts HistoryOptions) LineHistory {
+	return &memoryLineHistory{maxCount, make([]string, 0), opts}
 }
 
-// Put saves a new command to the history as latest entry.
+// Put saves a new line to the history as latest entry.
 func (h *memoryLineHistory) Put(line string) {
-	//TODO command deduplication
+	if line, ok := h.opts.apply(line); ok {
+		h.insert(line)
+	}
+}
+
+// insert adds an already filtered line as latest entry.
+func (h *memoryLineHistory) insert(line string) {
+	if h.opts.EraseDuplicates {
+		for i := range h.history {
+			if h.history[i] == line {
+				// remove old entry from list
+				h.history = append(h.history[:i], h.history[i+1:]...)
+				break
+			}
+		}
+	}
 
-	h.history[h.pos] = line
-	h.pos = (h.pos + 1) % len(h.history)
-	if h.count < len(h.history) {
-		h.count++
+	h.history = append([]string{line}, h.history...)
+	if len(h.history) > h.maxCount {
+		h.history = h.history[:h.maxCount]
 	}
 }
 
 // GetHistoryEntry can be used as history callback for ReadLineWithHistory.
 func (h *memoryLineHistory) GetHistoryEntry(index int) (string, bool) {
-	if index >= h.count {
+	if index < 0 || index >= len(h.history) {
 		return "", false
 	}
 
-	return h.history[(h.pos-1-index+len(h.history))%len(h.history)], true
+	return h.history[index], true
+}
+
+// Search can be used as history search callback for ReadLineWithHistory.
+func (h *memoryLineHistory) Search(query string, start int) (int, string, bool) {
+	for i := max(start, 0); i < len(h.history); i++ {
+		if strings.Contains(h.history[i], query) {
+			return i, h.history[i], true
+		}
+	}
+	return -1, "", false
 }
 
 // ReadLineWithHistory reads a line from Stdin and allows to select previous options using the Up and Down keys.
 func ReadLineWithHistory(history LineHistory) (string, error) {
+	return ReadLineWithHistoryContext(context.Background(), history)
+}
+
+// ReadLineWithHistoryContext reads a line like ReadLineWithHistory, but returns the context error as soon as ctx is done.
+func ReadLineWithHistoryContext(ctx context.Context, history LineHistory) (string, error) {
 	if err := console.BeginReadKey(); err != nil {
 		return "", err
 	}
 	defer console.EndReadKey()
 
-	return readLineWithHistory(history)
+	return readLineWithHistory(ctx, history)
 }
 
-func readLineWithHistory(history LineHistory) (string, error) {
+func readLineWithHistory(ctx context.Context, history LineHistory) (string, error) {
 	opts := ReadCommandOptions{
 		GetHistoryEntry: func(index int) ([]string, bool) {
 			if line, ok := history.GetHistoryEntry(index); ok {
@@ -58,7 +98,13 @@ func readLineWithHistory(history LineHistory) (string, error) {
 			}
 			return nil, false
 		},
+		SearchHistory: func(query string, start int) (int, []string, bool) {
+			if index, line, ok := history.Search(query, start); ok {
+				return index, []string{line}, true
+			}
+			return -1, nil, false
+		},
 	}
 
-	return readCommandLine(nil, "", false, &opts)
+	return readCommandLine(ctx, nil, "", false, &opts)
 }
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package commandline
+
+import (
+	"os"
+	"os/signal"
+
+	"golang.org/x/sys/unix"
+)
+
+// notifyResize relays terminal resize signals to the returned channel until stop is called.
+func notifyResize() (resized <-chan os.Signal, stop func()) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, unix.SIGWINCH)
+	return ch, func() { signal.Stop(ch) }
+}
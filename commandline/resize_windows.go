@@ -0,0 +1,12 @@
+//go:build windows
+
+package commandline
+
+import (
+	"os"
+)
+
+// notifyResize returns a nil channel because resize signals are not available. The line is updated on the next key press instead.
+func notifyResize() (resized <-chan os.Signal, stop func()) {
+	return nil, func() {}
+}
@@ -0,0 +1,217 @@
+package commandline
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sbreitf1/go-console"
+)
+
+// CommandHistorySearchHandler describes a function that returns the latest command from history at index start or older that contains query.
+type CommandHistorySearchHandler func(query string, start int) (index int, cmd []string, ok bool)
+
+var (
+	// searchMatchStyle is used to highlight the matched query in reverse search.
+	searchMatchStyle = console.Style{Bold: true, Underline: true}
+)
+
+// historySearch implements the interactive reverse incremental search in command history.
+type historySearch struct {
+	console     *console.Console
+	search      CommandHistorySearchHandler
+	cmdToString func([]string) string
+
+	query      []rune
+	matchIndex int
+	match      string
+	failed     bool
+	accepted   bool
+
+	// shown denotes whether the search line is visible on screen.
+	shown bool
+	// cursor denotes the position of the cursor relative to the beginning of the search line.
+	cursor cursorPos
+}
+
+// searchHandler returns the search callback of the options or iterates the history entries if only GetHistoryEntry is available.
+func (opts *ReadCommandOptions) searchHandler(cmdToString func([]string) string) CommandHistorySearchHandler {
+	if opts.SearchHistory != nil {
+		return opts.SearchHistory
+	}
+	if opts.GetHistoryEntry == nil {
+		return nil
+	}
+	return func(query string, start int) (int, []string, bool) {
+		for i := max(start, 0); ; i++ {
+			cmd, ok := opts.GetHistoryEntry(i)
+			if !ok {
+				return -1, nil, false
+			}
+			if strings.Contains(cmdToString(cmd), query) {
+				return i, cmd, true
+			}
+		}
+	}
+}
+
+// run processes key presses until the search is accepted or cancelled. ok is true if the returned key needs to be processed.
+func (s *historySearch) run(ctx context.Context, line *lineEditor) (event console.KeyEvent, ok bool, err error) {
+	originalLine, originalCaret := line.String(), line.Caret()
+	s.matchIndex = -1
+	s.render()
+
+	for {
+		event, err := s.console.ReadKeyEventContext(ctx)
+		if err != nil {
+			s.clear()
+			return console.KeyEvent{}, false, err
+		}
+
+		switch {
+		case event.Key == console.KeyCtrlR:
+			// jump to next older match
+			s.find(s.matchIndex + 1)
+
+		case event.Key == console.KeyCtrlC:
+			s.clear()
+			return console.KeyEvent{}, false, ErrCtrlC()
+
+		case event.Key == console.KeyEscape, event.Key == console.KeyCtrlG:
+			s.clear()
+			line.Replace(originalLine)
+			line.SetCaret(originalCaret)
+			return console.KeyEvent{}, false, nil
+
+		case event.Key == console.KeyEnter:
+			s.clear()
+			s.accept(line)
+			return console.KeyEvent{}, false, nil
+
+		case event.Key == console.KeyBackspace:
+			if len(s.query) > 0 {
+				s.query = s.query[:len(s.query)-1]
+				s.find(0)
+			}
+
+		case event.Modifiers.Has(console.ModAlt), event.Modifiers.Has(console.ModCtrl):
+			// other special keys end the search and are processed on the accepted line
+			s.clear()
+			s.accept(line)
+			return event, true, nil
+
+		case event.Key == console.KeySpace:
+			s.extendQuery(' ')
+		case event.Key == 0:
+			s.extendQuery(event.Rune)
+
+		default:
+			s.clear()
+			s.accept(line)
+			return event, true, nil
+		}
+
+		s.render()
+	}
+}
+
+func (s *historySearch) extendQuery(r rune) {
+	s.query = append(s.query, r)
+	if s.matchIndex >= 0 && !s.failed && strings.Contains(s.match, string(s.query)) {
+		// current match still fits
+		return
+	}
+	s.find(max(s.matchIndex, 0))
+}
+
+// find searches the query starting at the given history index. The last match is kept if nothing has been found.
+func (s *historySearch) find(start int) {
+	if len(s.query) == 0 {
+		s.failed = false
+		return
+	}
+
+	if index, cmd, ok := s.search(string(s.query), start); ok {
+		s.matchIndex = index
+		s.match = s.cmdToString(cmd)
+		s.failed = false
+	} else {
+		s.failed = true
+	}
+}
+
+func (s *historySearch) accept(line *lineEditor) {
+	if s.matchIndex < 0 {
+		return
+	}
+	line.Replace(s.match)
+	s.accepted = true
+	if pos := strings.Index(s.match, string(s.query)); pos >= 0 && len(s.query) > 0 {
+		// place caret at the match like bash does
+		line.SetCaret(len([]rune(s.match[:pos])))
+	}
+}
+
+// render prints the search line in place of the command line and places the cursor at the match.
+func (s *historySearch) render() {
+	prefix := "(reverse-i-search)"
+	if s.failed {
+		prefix = "(failed reverse-i-search)"
+	}
+	head := fmt.Sprintf("%s`%s': ", prefix, string(s.query))
+
+	var before, matched, after string
+	if s.matchIndex >= 0 {
+		pos := -1
+		if len(s.query) > 0 {
+			pos = strings.Index(s.match, string(s.query))
+		}
+		if pos >= 0 {
+			before, matched, after = s.match[:pos], s.match[pos:pos+len(string(s.query))], s.match[pos+len(string(s.query)):]
+		} else {
+			before = s.match
+		}
+	}
+
+	width := terminalWidth(s.console)
+	var sb strings.Builder
+	moveCursor(&sb, s.cursor, cursorPos{})
+	sb.WriteString(clearToScreenEnd)
+	sb.WriteString(head)
+	sb.WriteString(before)
+	sb.WriteString(s.console.SprintStyled(searchMatchStyle, matched))
+	sb.WriteString(after)
+
+	end := cursorPos{}.advance(head+before+matched+after, width)
+	if width > 0 && end.col >= width {
+		// resolve pending line wrap to have a well-defined cursor position
+		sb.WriteString(" \r")
+		end = cursorPos{end.row + 1, 0}
+	}
+	// move cursor back to the match
+	s.cursor = cursorPos{}.advance(head+before, width).before(console.StringWidth(firstGrapheme(matched+after)), width)
+	moveCursor(&sb, end, s.cursor)
+	s.console.Print(sb.String())
+	s.shown = true
+}
+
+// clear removes the search line from screen and moves the cursor to the beginning of the line.
+func (s *historySearch) clear() {
+	if !s.shown {
+		return
+	}
+	var sb strings.Builder
+	moveCursor(&sb, s.cursor, cursorPos{})
+	sb.WriteString(clearToScreenEnd)
+	s.console.Print(sb.String())
+	s.shown = false
+	s.cursor = cursorPos{}
+}
+
+// firstGrapheme returns the first grapheme cluster of str or an empty string.
+func firstGrapheme(str string) string {
+	if clusters := console.Graphemes(str); len(clusters) > 0 {
+		return clusters[0]
+	}
+	return ""
+}
@@ -0,0 +1,132 @@
+package commandline
+
+import (
+	"testing"
+
+	"github.com/sbreitf1/go-console"
+	"github.com/sbreitf1/go-console/consoletest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newSearchTestOptions(c *console.Console) *ReadCommandOptions {
+	history := NewCommandHistory(10)
+	history.Put([]string{"echo", "foo bar"})
+	history.Put([]string{"deploy", "prod"})
+	history.Put([]string{"echo", "baz"})
+	return &ReadCommandOptions{
+		GetHistoryEntry: history.GetHistoryEntry,
+		SearchHistory:   history.Search,
+		Console:         c,
+	}
+}
+
+func TestReadCommandReverseSearch(t *testing.T) {
+	c, input, output := consoletest.NewMockConsole()
+	input.PutKeys(console.KeyCtrlR)
+	input.PutString("ec")
+	input.PutKeys(console.KeyEnter, console.KeyEnter)
+
+	cmd, err := ReadCommand("", newSearchTestOptions(c))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"echo", "baz"}, cmd)
+	assert.Contains(t, output.String(), "(reverse-i-search)`ec': echo baz")
+	input.AssertBufferConsumed(t)
+}
+
+func TestReadCommandReverseSearchOlderMatches(t *testing.T) {
+	c, input, _ := consoletest.NewMockConsole()
+	input.PutKeys(console.KeyCtrlR)
+	input.PutString("o")
+	input.PutKeys(console.KeyCtrlR, console.KeyCtrlR, console.KeyEnter, console.KeyEnter)
+
+	cmd, err := ReadCommand("", newSearchTestOptions(c))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"echo", "foo bar"}, cmd)
+	input.AssertBufferConsumed(t)
+}
+
+func TestReadCommandReverseSearchFailed(t *testing.T) {
+	c, input, output := consoletest.NewMockConsole()
+	input.PutKeys(console.KeyCtrlR)
+	input.PutString("depx")
+	input.PutKeys(console.KeyEnter, console.KeyEnter)
+
+	cmd, err := ReadCommand("", newSearchTestOptions(c))
+	assert.NoError(t, err)
+	// last successful match is kept
+	assert.Equal(t, []string{"deploy", "prod"}, cmd)
+	assert.Contains(t, output.String(), "(failed reverse-i-search)`depx': deploy prod")
+	input.AssertBufferConsumed(t)
+}
+
+func TestReadCommandReverseSearchBackspace(t *testing.T) {
+	c, input, _ := consoletest.NewMockConsole()
+	input.PutKeys(console.KeyCtrlR)
+	input.PutString("foo\rr\rr")
+	input.PutKeys(console.KeyEnter, console.KeyEnter)
+
+	cmd, err := ReadCommand("", newSearchTestOptions(c))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"echo", "foo bar"}, cmd)
+	input.AssertBufferConsumed(t)
+}
+
+func TestReadCommandReverseSearchCancel(t *testing.T) {
+	c, input, _ := consoletest.NewMockConsole()
+	input.PutString("ls")
+	input.PutKeys(console.KeyCtrlR)
+	input.PutString("dep")
+	input.PutKeys(console.KeyEscape)
+	input.PutString(" -l\n")
+
+	cmd, err := ReadCommand("", newSearchTestOptions(c))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ls", "-l"}, cmd)
+	input.AssertBufferConsumed(t)
+}
+
+func TestReadCommandReverseSearchEditMatch(t *testing.T) {
+	c, input, _ := consoletest.NewMockConsole()
+	input.PutKeys(console.KeyCtrlR)
+	input.PutString("prod")
+	// caret is placed at the match, End is processed on the accepted line
+	input.PutKeys(console.KeyEnd)
+	input.PutString("uction\n")
+
+	cmd, err := ReadCommand("", newSearchTestOptions(c))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"deploy", "production"}, cmd)
+	input.AssertBufferConsumed(t)
+}
+
+func TestReadLineWithHistoryReverseSearch(t *testing.T) {
+	history := NewLineHistory(5)
+	history.Put("first line")
+	history.Put("second line")
+
+	consoletest.WithMocks(func(input *consoletest.MockInput) {
+		input.PutKeys(console.KeyCtrlR)
+		input.PutString("fir")
+		input.PutKeys(console.KeyEnter, console.KeyEnter)
+		l, err := ReadLineWithHistory(history)
+		assert.NoError(t, err)
+		assert.Equal(t, "first line", l)
+		input.AssertBufferConsumed(t)
+	})
+}
+
+func TestSearchHandlerFallback(t *testing.T) {
+	history := NewCommandHistory(10)
+	history.Put([]string{"foo"})
+	history.Put([]string{"bar"})
+	opts := &ReadCommandOptions{GetHistoryEntry: history.GetHistoryEntry}
+
+	index, cmd, ok := opts.searchHandler(GetCommandString)("o", 0)
+	assert.True(t, ok)
+	assert.Equal(t, 1, index)
+	assert.Equal(t, []string{"foo"}, cmd)
+
+	_, _, ok = opts.searchHandler(GetCommandString)("x", 0)
+	assert.False(t, ok)
+}
@@ -0,0 +1,59 @@
+package commandline
+
+import (
+	"strings"
+)
+
+// Suggester provides inline suggestions while typing a command.
+type Suggester interface {
+	// Suggest returns a complete line that starts with input. ok is false when nothing can be suggested.
+	Suggest(input string) (suggestion string, ok bool)
+}
+
+// SuggesterFunc is an adapter to use an ordinary function as Suggester.
+type SuggesterFunc func(input string) (string, bool)
+
+// Suggest calls f(input).
+func (f SuggesterFunc) Suggest(input string) (string, bool) {
+	return f(input)
+}
+
+// NewHistorySuggester returns a Suggester that suggests the most recent command from history starting with the input.
+func NewHistorySuggester(history CommandHistory) Suggester {
+	return SuggesterFunc(func(input string) (string, bool) {
+		return suggestFromHistory(history, input)
+	})
+}
+
+func suggestFromHistory(history CommandHistory, input string) (string, bool) {
+	if len(input) == 0 {
+		return "", false
+	}
+	for i := 0; ; i++ {
+		cmd, ok := history.GetHistoryEntry(i)
+		if !ok {
+			return "", false
+		}
+		str := GetCommandString(cmd)
+		if len(str) > len(input) && strings.HasPrefix(str, input) && !strings.Contains(str, "\n") {
+			return str, true
+		}
+	}
+}
+
+// suggestionHint returns the part of the suggestion that is displayed after the input line.
+func suggestionHint(suggester Suggester, input string) string {
+	if suggester == nil || len(input) == 0 {
+		return ""
+	}
+	suggestion, ok := suggester.Suggest(input)
+	if !ok || len(suggestion) <= len(input) || !strings.HasPrefix(suggestion, input) {
+		return ""
+	}
+	hint := suggestion[len(input):]
+	if pos := strings.IndexAny(hint, "\r\n"); pos >= 0 {
+		// only single line suggestions can be displayed
+		hint = hint[:pos]
+	}
+	return hint
+}
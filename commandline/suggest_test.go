@@ -0,0 +1,81 @@
+package commandline
+
+import (
+	"testing"
+
+	"github.com/sbreitf1/go-console"
+	"github.com/sbreitf1/go-console/consoletest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistorySuggester(t *testing.T) {
+	history := NewCommandHistory(10)
+	history.Put([]string{"deploy", "staging"})
+	history.Put([]string{"deploy", "prod env"})
+	history.Put([]string{"status"})
+	suggester := NewHistorySuggester(history)
+
+	suggestion, ok := suggester.Suggest("dep")
+	assert.True(t, ok)
+	assert.Equal(t, `deploy "prod env"`, suggestion)
+	suggestion, ok = suggester.Suggest("deploy s")
+	assert.True(t, ok)
+	assert.Equal(t, "deploy staging", suggestion)
+
+	_, ok = suggester.Suggest("status")
+	assert.False(t, ok)
+	_, ok = suggester.Suggest("")
+	assert.False(t, ok)
+	_, ok = suggester.Suggest("foo")
+	assert.False(t, ok)
+}
+
+func TestSuggestionHint(t *testing.T) {
+	suggester := SuggesterFunc(func(input string) (string, bool) {
+		return map[string]string{
+			"multi": "multi\nline",
+			"foo":   "foobar",
+			"bad":   "other",
+		}[input], true
+	})
+	assert.Equal(t, "bar", suggestionHint(suggester, "foo"))
+	assert.Equal(t, "", suggestionHint(suggester, "multi"))
+	assert.Equal(t, "", suggestionHint(suggester, "bad"))
+	assert.Equal(t, "", suggestionHint(nil, "foo"))
+}
+
+func TestCommandLineEnvironmentSuggestions(t *testing.T) {
+	c, input, output := consoletest.NewMockConsole()
+	output.Colors = true
+	history := NewCommandHistory(10)
+	history.Put([]string{"print", "foo", "bar"})
+
+	cle, _, sb := prepareTestCLE()
+	cle.Console = c
+	cle.SetStaticPrompt("")
+	cle.SetHistory(history)
+	// only check output of suggestions
+	cle.Highlighter = nil
+
+	// accept whole suggestion
+	input.PutString("pr")
+	input.PutKeys(console.KeyRight)
+	input.PutString("\n")
+	// accept single words
+	input.PutString("pr")
+	input.PutKeyEvents(console.KeyEvent{Rune: 'f', Modifiers: console.ModAlt}, console.KeyEvent{Rune: 'f', Modifiers: console.ModAlt})
+	input.PutString("\n")
+	// ignore suggestion
+	input.PutString("pr")
+	input.PutKeys(console.KeyCtrlK)
+	input.PutString("int x\n")
+	input.PutString("exit\n")
+
+	assert.NoError(t, cle.Run())
+	assert.Equal(t, ">foo<>bar<|>foo<|>x<|", sb.String())
+	input.AssertBufferConsumed(t)
+	assert.Contains(t, output.String(), "> p\x1b[90mrint foo bar\x1b[0m")
+	// remaining suggestion is erased on submit
+	assert.Contains(t, output.String(), " foo\x1b[90m bar\x1b[0m\b\b\b\b    \b\b\b\b\n")
+}
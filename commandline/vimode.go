@@ -0,0 +1,368 @@
+package commandline
+
+import (
+	"unicode"
+
+	"github.com/sbreitf1/go-console"
+)
+
+// EditingMode denotes the key bindings used for line editing.
+type EditingMode int
+
+const (
+	// EditingModeEmacs denotes readline-like key bindings. Esc clears the line.
+	EditingModeEmacs EditingMode = iota
+	// EditingModeVi denotes vi-like key bindings. Esc switches from insert mode to normal mode.
+	EditingModeVi
+)
+
+// viResult tells the caller how to continue after a key event has been passed to vi mode.
+type viResult int
+
+const (
+	// viForward denotes a key event that needs to be processed by the default key bindings.
+	viForward viResult = iota
+	// viHandled denotes a key event that has been processed completely.
+	viHandled
+	// viRepeat denotes a request to repeat the last change.
+	viRepeat
+)
+
+type viSnapshot struct {
+	line  string
+	caret int
+}
+
+// viEditor holds the state of the vi editing mode for a single line.
+type viEditor struct {
+	// normal is true in normal mode and false in insert mode.
+	normal bool
+	// operator holds the pending operator (d, c or y) that waits for a motion.
+	operator *console.KeyEvent
+	// register holds the text of the latest delete or yank for pasting.
+	register string
+
+	// undo holds the line states before each change.
+	undo []viSnapshot
+	// changing is true while a change is in progress, e.g. during insert mode.
+	changing bool
+
+	// change holds the key events of the recorded change in progress.
+	change    []console.KeyEvent
+	recording bool
+	// lastChange holds the key events of the latest completed change for repetition.
+	lastChange []console.KeyEvent
+	// replaying is true while the last change is repeated. Replayed key events are not recorded.
+	replaying bool
+}
+
+func newViEditor() *viEditor {
+	// the line is read in insert mode, which can be undone from normal mode
+	return &viEditor{undo: []viSnapshot{{"", 0}}, changing: true}
+}
+
+// handle processes a key event. The returned event needs to be processed by the default key bindings if viForward is returned.
+func (vi *viEditor) handle(event console.KeyEvent, line *lineEditor) (console.KeyEvent, viResult) {
+	if !vi.normal {
+		if event.Key == console.KeyEscape {
+			vi.record(event)
+			vi.enterNormalMode(line)
+			return event, viHandled
+		}
+		vi.record(event)
+		return event, viForward
+	}
+
+	if vi.operator != nil {
+		vi.applyOperator(event, line)
+		return event, viHandled
+	}
+
+	if event.Modifiers.Has(console.ModAlt) {
+		return event, viForward
+	}
+
+	switch event.Key {
+	case 0:
+	case console.KeyLeft, console.KeyBackspace:
+		event = console.KeyEvent{Rune: 'h'}
+	case console.KeyRight, console.KeySpace:
+		event = console.KeyEvent{Rune: 'l'}
+	case console.KeyHome:
+		event = console.KeyEvent{Rune: '0'}
+	case console.KeyEnd:
+		event = console.KeyEvent{Rune: '$'}
+	case console.KeyDelete:
+		event = console.KeyEvent{Rune: 'x'}
+	case console.KeyEscape, console.KeyTab:
+		return event, viHandled
+	default:
+		return event, viForward
+	}
+
+	switch r := event.Rune; r {
+	case 'h', 'l', 'w', 'b', 'e', '0', '$':
+		target, _, _ := viMotion(r, line)
+		line.SetCaret(target)
+		vi.clampCaret(line)
+
+	case 'i':
+		vi.beginChange(event, line)
+		vi.normal = false
+	case 'a':
+		vi.beginChange(event, line)
+		line.MoveCaretRight()
+		vi.normal = false
+	case 'I':
+		vi.beginChange(event, line)
+		line.MoveCaretToLineBegin()
+		vi.normal = false
+	case 'A':
+		vi.beginChange(event, line)
+		line.MoveCaretToLineEnd()
+		vi.normal = false
+
+	case 'x':
+		if line.Len() > 0 {
+			vi.beginChange(event, line)
+			vi.register = line.RemoveRange(line.Caret(), clusterAfter(line.buffer, line.Caret()))
+			vi.clampCaret(line)
+			vi.endChange(line)
+		}
+	case 'D', 'C':
+		vi.beginChange(event, line)
+		vi.register = line.KillToLineEnd()
+		if r == 'C' {
+			vi.normal = false
+		} else {
+			vi.clampCaret(line)
+			vi.endChange(line)
+		}
+
+	case 'p', 'P':
+		if len(vi.register) > 0 {
+			vi.beginChange(event, line)
+			if r == 'p' {
+				line.MoveCaretRight()
+			}
+			line.InsertAtCaret(vi.register)
+			// caret rests on the last pasted rune
+			line.MoveCaretLeft()
+			vi.endChange(line)
+		}
+
+	case 'd', 'c', 'y':
+		vi.operator = &event
+
+	case 'u':
+		vi.undoChange(line)
+	case '.':
+		if len(vi.lastChange) > 0 {
+			return event, viRepeat
+		}
+
+	case 'j':
+		return console.KeyEvent{Key: console.KeyDown}, viForward
+	case 'k':
+		return console.KeyEvent{Key: console.KeyUp}, viForward
+	}
+	return event, viHandled
+}
+
+// applyOperator applies the pending operator with the motion denoted by event.
+func (vi *viEditor) applyOperator(event console.KeyEvent, line *lineEditor) {
+	opEvent := *vi.operator
+	op := opEvent.Rune
+	vi.operator = nil
+	if event.Key != 0 || event.Modifiers != 0 {
+		// cancel operator
+		return
+	}
+
+	caret := line.Caret()
+	var start, end int
+	if event.Rune == op {
+		// doubled operator applies to the whole line
+		start, end = 0, line.Len()
+	} else {
+		motion := event.Rune
+		if op == 'c' && motion == 'w' && caret < line.Len() && !unicode.IsSpace(line.buffer[caret]) {
+			// cw changes to the end of the word like in vi
+			motion = 'e'
+		}
+		target, inclusive, ok := viMotion(motion, line)
+		if !ok {
+			return
+		}
+		start, end = min(caret, target), max(caret, target)
+		if inclusive {
+			end = clusterAfter(line.buffer, end)
+		}
+	}
+
+	if op == 'y' {
+		vi.register = string(line.buffer[start:end])
+		if event.Rune != op {
+			line.SetCaret(start)
+			vi.clampCaret(line)
+		}
+		return
+	}
+
+	vi.beginChange(opEvent, line)
+	vi.record(event)
+	vi.register = line.RemoveRange(start, end)
+	if op == 'c' {
+		vi.normal = false
+	} else {
+		vi.clampCaret(line)
+		vi.endChange(line)
+	}
+}
+
+// enterNormalMode leaves insert mode and completes the current change.
+func (vi *viEditor) enterNormalMode(line *lineEditor) {
+	vi.normal = true
+	line.MoveCaretLeft()
+	vi.endChange(line)
+}
+
+// clampCaret keeps the caret on the last grapheme cluster in normal mode.
+func (vi *viEditor) clampCaret(line *lineEditor) {
+	if line.Len() > 0 && line.Caret() >= line.Len() {
+		line.SetCaret(clusterBefore(line.buffer, line.Len()))
+	}
+}
+
+// beginChange saves the line state for undo and starts recording the change for repetition.
+func (vi *viEditor) beginChange(event console.KeyEvent, line *lineEditor) {
+	vi.undo = append(vi.undo, viSnapshot{line.String(), line.Caret()})
+	vi.changing = true
+	if !vi.replaying {
+		vi.change = []console.KeyEvent{event}
+		vi.recording = true
+	}
+}
+
+func (vi *viEditor) record(event console.KeyEvent) {
+	if vi.recording && !vi.replaying {
+		vi.change = append(vi.change, event)
+	}
+}
+
+// endChange completes the current change. Changes that did not modify the line are not kept for undo.
+func (vi *viEditor) endChange(line *lineEditor) {
+	if !vi.changing {
+		return
+	}
+	vi.changing = false
+	if n := len(vi.undo); n > 0 && vi.undo[n-1].line == line.String() {
+		vi.undo = vi.undo[:n-1]
+	}
+	if vi.recording {
+		vi.lastChange = vi.change
+		vi.change = nil
+		vi.recording = false
+	}
+}
+
+func (vi *viEditor) undoChange(line *lineEditor) {
+	n := len(vi.undo)
+	if n == 0 {
+		return
+	}
+	snapshot := vi.undo[n-1]
+	vi.undo = vi.undo[:n-1]
+	line.Replace(snapshot.line)
+	line.SetCaret(snapshot.caret)
+	vi.clampCaret(line)
+}
+
+// repetition returns a copy of the key events of the last change.
+func (vi *viEditor) repetition() []console.KeyEvent {
+	return append([]console.KeyEvent{}, vi.lastChange...)
+}
+
+// viMotion returns the target caret position of a motion. Inclusive motions also cover the rune at the target position.
+func viMotion(motion rune, line *lineEditor) (target int, inclusive bool, ok bool) {
+	buf, caret := line.buffer, line.caret
+	switch motion {
+	case 'h':
+		return clusterBefore(buf, caret), false, true
+	case 'l':
+		return clusterAfter(buf, caret), false, true
+	case 'w':
+		return viWordForward(buf, caret), false, true
+	case 'b':
+		return viWordBackward(buf, caret), false, true
+	case 'e':
+		return viWordEnd(buf, caret), true, true
+	case '0':
+		return 0, false, true
+	case '$':
+		return len(buf), false, true
+	}
+	return caret, false, false
+}
+
+// viRuneClass returns 0 for whitespace, 1 for word runes and 2 for other runes. Words in vi consist of runes of the same class.
+func viRuneClass(r rune) int {
+	if unicode.IsSpace(r) {
+		return 0
+	}
+	if r == '_' || isWordRune(r) {
+		return 1
+	}
+	return 2
+}
+
+// viWordForward returns the beginning of the next word.
+func viWordForward(buf []rune, pos int) int {
+	if pos >= len(buf) {
+		return len(buf)
+	}
+	if class := viRuneClass(buf[pos]); class != 0 {
+		for pos < len(buf) && viRuneClass(buf[pos]) == class {
+			pos++
+		}
+	}
+	for pos < len(buf) && viRuneClass(buf[pos]) == 0 {
+		pos++
+	}
+	return pos
+}
+
+// viWordBackward returns the beginning of the current or previous word.
+func viWordBackward(buf []rune, pos int) int {
+	pos = min(pos, len(buf)) - 1
+	for pos > 0 && viRuneClass(buf[pos]) == 0 {
+		pos--
+	}
+	if pos <= 0 {
+		return 0
+	}
+	class := viRuneClass(buf[pos])
+	for pos > 0 && viRuneClass(buf[pos-1]) == class {
+		pos--
+	}
+	return pos
+}
+
+// viWordEnd returns the last rune of the current or next word.
+func viWordEnd(buf []rune, pos int) int {
+	if len(buf) == 0 {
+		return 0
+	}
+	pos++
+	for pos < len(buf) && viRuneClass(buf[pos]) == 0 {
+		pos++
+	}
+	if pos >= len(buf) {
+		return len(buf) - 1
+	}
+	class := viRuneClass(buf[pos])
+	for pos+1 < len(buf) && viRuneClass(buf[pos+1]) == class {
+		pos++
+	}
+	return pos
+}
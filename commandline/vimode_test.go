@@ -0,0 +1,170 @@
+package commandline
+
+import (
+	"testing"
+
+	"github.com/sbreitf1/go-console"
+	"github.com/sbreitf1/go-console/consoletest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testViCommand(t *testing.T, expected []string, opts *ReadCommandOptions, put func(input *consoletest.MockInput)) {
+	consoletest.WithMocks(func(input *consoletest.MockInput) {
+		put(input)
+		if opts == nil {
+			opts = &ReadCommandOptions{}
+		}
+		opts.EditingMode = EditingModeVi
+		cmd, err := ReadCommand("", opts)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, cmd)
+		input.AssertBufferConsumed(t)
+	})
+}
+
+func TestViModeEscapeKeepsLine(t *testing.T) {
+	testViCommand(t, []string{"foo"}, nil, func(input *consoletest.MockInput) {
+		input.PutString("foo")
+		input.PutKeys(console.KeyEscape)
+		input.PutString("\n")
+	})
+}
+
+func TestViModeMotions(t *testing.T) {
+	testViCommand(t, []string{"xfoo.bar", "bazy!"}, nil, func(input *consoletest.MockInput) {
+		input.PutString("foo.bar baz")
+		input.PutKeys(console.KeyEscape)
+		input.PutString("0ix")
+		input.PutKeys(console.KeyEscape)
+		input.PutString("$ay")
+		input.PutKeys(console.KeyEscape)
+		input.PutString("bbbehl")
+		input.PutKeys(console.KeyEscape)
+		input.PutString("A!\n")
+	})
+}
+
+func TestViWordMotions(t *testing.T) {
+	buf := []rune("foo.bar  baz")
+	tests := []struct {
+		fn       func([]rune, int) int
+		pos      int
+		expected int
+	}{
+		{viWordForward, 0, 3},
+		{viWordForward, 3, 4},
+		{viWordForward, 4, 9},
+		{viWordForward, 9, 12},
+		{viWordBackward, 12, 9},
+		{viWordBackward, 9, 4},
+		{viWordBackward, 4, 3},
+		{viWordBackward, 3, 0},
+		{viWordBackward, 0, 0},
+		{viWordEnd, 0, 2},
+		{viWordEnd, 2, 3},
+		{viWordEnd, 3, 6},
+		{viWordEnd, 6, 11},
+		{viWordEnd, 11, 11},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, test.fn(buf, test.pos))
+	}
+}
+
+func TestViModeDelete(t *testing.T) {
+	testViCommand(t, []string{"foo", "ba"}, nil, func(input *consoletest.MockInput) {
+		input.PutString("foo bar baz")
+		input.PutKeys(console.KeyEscape)
+		input.PutString("0wdw$x\n")
+	})
+}
+
+func TestViModeDeleteLine(t *testing.T) {
+	testViCommand(t, []string{"bar"}, nil, func(input *consoletest.MockInput) {
+		input.PutString("foo")
+		input.PutKeys(console.KeyEscape)
+		input.PutString("ddibar\n")
+	})
+}
+
+func TestViModeChange(t *testing.T) {
+	testViCommand(t, []string{"foo", "qux", "baz"}, nil, func(input *consoletest.MockInput) {
+		input.PutString("foo bar baz")
+		input.PutKeys(console.KeyEscape)
+		input.PutString("bbcwqux")
+		input.PutKeys(console.KeyEscape)
+		input.PutString("\n")
+	})
+}
+
+func TestViModeChangeLine(t *testing.T) {
+	testViCommand(t, []string{"bar"}, nil, func(input *consoletest.MockInput) {
+		input.PutString("foo")
+		input.PutKeys(console.KeyEscape)
+		input.PutString("ccbar\n")
+	})
+}
+
+func TestViModeYankAndPaste(t *testing.T) {
+	testViCommand(t, []string{"ab", "cdab"}, nil, func(input *consoletest.MockInput) {
+		input.PutString("ab cd")
+		input.PutKeys(console.KeyEscape)
+		input.PutString("0ye$p")
+		input.PutKeys(console.KeyEscape)
+		input.PutString("\n")
+	})
+}
+
+func TestViModeUndo(t *testing.T) {
+	testViCommand(t, []string{"fo", "bar"}, nil, func(input *consoletest.MockInput) {
+		input.PutString("foo")
+		input.PutKeys(console.KeyEscape)
+		input.PutString("xxxuA bar")
+		input.PutKeys(console.KeyEscape)
+		input.PutString("uu")
+		input.PutString("A bar\n")
+	})
+}
+
+func TestViModeUndoInitialInsert(t *testing.T) {
+	testViCommand(t, []string{"bar"}, nil, func(input *consoletest.MockInput) {
+		input.PutString("foo")
+		input.PutKeys(console.KeyEscape)
+		input.PutString("uibar\n")
+	})
+}
+
+func TestViModeRepeat(t *testing.T) {
+	testViCommand(t, []string{"b", "c"}, nil, func(input *consoletest.MockInput) {
+		input.PutString("a b c")
+		input.PutKeys(console.KeyEscape)
+		input.PutString("0x.\n")
+	})
+}
+
+func TestViModeRepeatInsert(t *testing.T) {
+	testViCommand(t, []string{"xyzyz"}, nil, func(input *consoletest.MockInput) {
+		input.PutString("x")
+		input.PutKeys(console.KeyEscape)
+		input.PutString("ayz")
+		input.PutKeys(console.KeyEscape)
+		input.PutString(".\n")
+	})
+}
+
+func TestViModeHistory(t *testing.T) {
+	history := [][]string{{"latest"}, {"older"}}
+	opts := &ReadCommandOptions{
+		GetHistoryEntry: func(index int) ([]string, bool) {
+			if index < len(history) {
+				return history[index], true
+			}
+			return nil, false
+		},
+	}
+	testViCommand(t, []string{"latest!"}, opts, func(input *consoletest.MockInput) {
+		input.PutKeys(console.KeyEscape)
+		input.PutString("kkjA!\n")
+	})
+}
@@ -1,11 +1,12 @@
 package console
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"reflect"
 	"strings"
-	"unicode/utf8"
 
 	"golang.org/x/term"
 )
@@ -20,15 +21,20 @@ var (
 	// DefaultOutput can be used to redirect output destinations.
 	DefaultOutput Output
 
+	defaultConsole = &Console{}
+
 	newline string
 )
 
 // Input defines functionality to handle console input.
 type Input interface {
 	ReadLine() (string, error)
+	ReadLineContext(context.Context) (string, error)
 	ReadPassword() (string, error)
 	BeginReadKey() error
 	ReadKey() (Key, rune, error)
+	ReadKeyEvent() (KeyEvent, error)
+	ReadKeyEventContext(context.Context) (KeyEvent, error)
 	EndReadKey() error
 }
 
@@ -40,73 +46,118 @@ type Output interface {
 	Exit(int)
 }
 
-type defaultInput struct {
-	lastCharWasCR bool
+func init() {
+	DefaultInput = &terminalInput{}
+	DefaultOutput = &writerOutput{w: os.Stdout}
+	newline = fmt.Sprintln()
 }
-type defaultOutput struct {
+
+// Console represents a terminal with its own input, output and raw mode state. A nil *Console behaves like Default.
+type Console struct {
+	input  Input
+	output Output
 }
 
-func init() {
-	DefaultInput = &defaultInput{}
-	DefaultOutput = &defaultOutput{}
-	newline = fmt.Sprintln()
+// Options configures a Console created by New.
+type Options struct {
+	// GetSize returns the terminal dimensions in characters. Required to use PrintList on outputs that are no terminal.
+	GetSize func() (int, int, error)
+	// SupportsColors enables ANSI colors for outputs that are no terminal.
+	SupportsColors bool
+	// ColorDepth denotes the supported colors for outputs that are no terminal. Defaults to 16 colors when SupportsColors is set.
+	ColorDepth ColorDepth
+	// Exit is called by the Fatal functions. Defaults to os.Exit.
+	Exit func(int)
 }
 
-func (d *defaultOutput) Print(str string) (int, error) {
-	return fmt.Print(str)
+// New returns a console that reads from in and writes to out. opts can be nil.
+func New(in io.Reader, out io.Writer, opts *Options) *Console {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	var input Input
+	if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		input = &terminalInput{file: f}
+	} else {
+		input = newStreamInput(in)
+	}
+	return &Console{input, &writerOutput{out, *opts}}
 }
 
-// Print writes a set of objects separated by whitespaces to Stdout.
-func Print(a ...interface{}) (int, error) {
-	return DefaultOutput.Print(fmt.Sprint(a...))
+// NewWithInterfaces returns a console for custom Input and Output implementations.
+func NewWithInterfaces(input Input, output Output) *Console {
+	return &Console{input, output}
 }
 
-// Printf writes a formatted string to Stdout.
-func Printf(format string, a ...interface{}) (int, error) {
-	return DefaultOutput.Print(fmt.Sprintf(format, a...))
+// Default returns the console used by all package-level functions. It always uses the current DefaultInput and DefaultOutput.
+func Default() *Console {
+	return defaultConsole
 }
 
-// Println writes a set of objects separated by whitespaces to Stdout and ends the line.
-func Println(a ...interface{}) (int, error) {
-	return DefaultOutput.Print(fmt.Sprintln(a...))
+// Input returns the input of the console.
+func (c *Console) Input() Input {
+	if c == nil || c.input == nil {
+		return DefaultInput
+	}
+	return c.input
 }
 
-// Printlnf writes a formatted string to Stdout and ends the line.
-func Printlnf(format string, a ...interface{}) (int, error) {
-	return Println(fmt.Sprintf(format, a...))
+// Output returns the output of the console.
+func (c *Console) Output() Output {
+	if c == nil || c.output == nil {
+		return DefaultOutput
+	}
+	return c.output
+}
+
+// Print writes a set of objects separated by whitespaces to the console.
+func (c *Console) Print(a ...interface{}) (int, error) {
+	return c.Output().Print(fmt.Sprint(a...))
+}
+
+// Printf writes a formatted string to the console.
+func (c *Console) Printf(format string, a ...interface{}) (int, error) {
+	return c.Output().Print(fmt.Sprintf(format, a...))
+}
+
+// Println writes a set of objects separated by whitespaces to the console and ends the line.
+func (c *Console) Println(a ...interface{}) (int, error) {
+	return c.Output().Print(fmt.Sprintln(a...))
+}
+
+// Printlnf writes a formatted string to the console and ends the line.
+func (c *Console) Printlnf(format string, a ...interface{}) (int, error) {
+	return c.Println(fmt.Sprintf(format, a...))
 }
 
 // Fatal calls Print and exits with code 1.
-func Fatal(a ...interface{}) {
-	fatalWrapper(Print(a...))
+func (c *Console) Fatal(a ...interface{}) {
+	c.fatalWrapper(c.Print(a...))
 }
 
 // Fatalf calls Printf and exits with code 1.
-func Fatalf(format string, a ...interface{}) {
-	fatalWrapper(Printf(format, a...))
+func (c *Console) Fatalf(format string, a ...interface{}) {
+	c.fatalWrapper(c.Printf(format, a...))
 }
 
 // Fatalln calls Println and exits with code 1.
-func Fatalln(a ...interface{}) {
-	fatalWrapper(Println(a...))
+func (c *Console) Fatalln(a ...interface{}) {
+	c.fatalWrapper(c.Println(a...))
 }
 
 // Fatallnf calls Printlnf and exits with code 1.
-func Fatallnf(format string, a ...interface{}) {
-	fatalWrapper(Printlnf(format, a...))
-}
-
-func (d *defaultOutput) Exit(code int) {
-	os.Exit(code)
+func (c *Console) Fatallnf(format string, a ...interface{}) {
+	c.fatalWrapper(c.Printlnf(format, a...))
 }
 
-func fatalWrapper(int, error) {
-	DefaultOutput.Exit(1)
+func (c *Console) fatalWrapper(int, error) {
+	c.Output().Exit(1)
 }
 
 // PrintList prints all array or map values in a regular grid.
-func PrintList(obj interface{}) error {
-	width, _, err := GetSize()
+func (c *Console) PrintList(obj interface{}) error {
+	width, _, err := c.GetSize()
 	if err != nil {
 		return err
 	}
@@ -115,24 +166,20 @@ func PrintList(obj interface{}) error {
 
 	maxItemLen := 0
 	for _, item := range list {
-		if len(item) > maxItemLen {
-			maxItemLen = len(item)
-		}
+		maxItemLen = max(maxItemLen, StringWidth(item))
 	}
 
 	var sb strings.Builder
 	space := strings.Repeat(" ", listSpaceLen)
 
 	itemsPerLine := (width + listSpaceLen) / (maxItemLen + listSpaceLen)
-	lineCount := len(list) / itemsPerLine
-	if len(list) > (lineCount * itemsPerLine) {
-		lineCount++
-	}
-
 	if itemsPerLine == 0 {
 		// fallback for very small terminals (or exceedingly large list items)
 		itemsPerLine = 1
-		lineCount = len(list)
+	}
+	lineCount := len(list) / itemsPerLine
+	if len(list) > (lineCount * itemsPerLine) {
+		lineCount++
 	}
 
 	for l := 0; l < lineCount; l++ {
@@ -145,12 +192,12 @@ func PrintList(obj interface{}) error {
 				sb.WriteString(space)
 			}
 			sb.WriteString(list[index])
-			sb.WriteString(strings.Repeat(" ", maxItemLen-len(list[index])))
+			sb.WriteString(strings.Repeat(" ", maxItemLen-StringWidth(list[index])))
 		}
 		sb.WriteString(newline)
 	}
 
-	_, err = Print(sb.String())
+	_, err = c.Print(sb.String())
 	return err
 }
 
@@ -189,156 +236,184 @@ func toList(obj interface{}) []string {
 	return list
 }
 
-func (d *defaultOutput) GetSize() (int, int, error) {
-	return term.GetSize(int(os.Stdout.Fd()))
+// GetSize returns the current terminal dimensions in characters.
+func (c *Console) GetSize() (int, int, error) {
+	return c.Output().GetSize()
 }
 
-// GetSize returns the current terminal dimensions in characters.
-func GetSize() (int, int, error) {
-	return DefaultOutput.GetSize()
+// SupportsColors returns true when the terminal supports ANSI colors.
+func (c *Console) SupportsColors() bool {
+	return c.Output().SupportsColors()
 }
 
-func (d *defaultOutput) SupportsColors() bool {
-	return supportsColors()
+// ReadLine reads a line from the console input.
+func (c *Console) ReadLine() (string, error) {
+	return c.Input().ReadLine()
 }
 
-// SupportsColors returns true when the current terminal supports ANSI colors.
-func SupportsColors() bool {
-	return DefaultOutput.SupportsColors()
+// ReadLineContext reads a line like ReadLine, but returns the context error as soon as ctx is done.
+func (c *Console) ReadLineContext(ctx context.Context) (string, error) {
+	return c.Input().ReadLineContext(ctx)
 }
 
-func (d *defaultInput) ReadLine() (string, error) {
-	//TODO configurable encoding
-	return d.readLine(d.readRuneUTF8)
+// ReadPassword reads a line from the console input while hiding the user input.
+func (c *Console) ReadPassword() (string, error) {
+	pw, err := c.Input().ReadPassword()
+	if err != nil {
+		return "", err
+	}
+	// print suppressed line-feed
+	c.Println()
+	return pw, nil
 }
 
-func (d *defaultInput) readLine(readRune func() (rune, error)) (string, error) {
-	var sb strings.Builder
+// BeginReadKey switches the console input to raw mode and allows you to use ReadKey.
+func (c *Console) BeginReadKey() error {
+	return c.Input().BeginReadKey()
+}
 
-	for {
-		r, err := readRune()
-		if err != nil {
-			return sb.String(), err
-		}
+// ReadKey returns a key and the corresponding rune or an error. BeginReadKey needs to be called first.
+func (c *Console) ReadKey() (Key, rune, error) {
+	return c.Input().ReadKey()
+}
 
-		if r == '\r' {
-			d.lastCharWasCR = true
-			return sb.String(), nil
-		} else if r == '\n' {
-			if d.lastCharWasCR {
-				// just ignore that char to be compatible with windows \r\n
-				d.lastCharWasCR = false
-			} else {
-				d.lastCharWasCR = false
-				return sb.String(), nil
-			}
-		} else {
-			d.lastCharWasCR = false
-			sb.WriteRune(r)
-		}
-	}
+// ReadKeyContext returns a key like ReadKey, but returns the context error as soon as ctx is done. BeginReadKey needs to be called first.
+func (c *Console) ReadKeyContext(ctx context.Context) (Key, rune, error) {
+	event, err := c.Input().ReadKeyEventContext(ctx)
+	return event.Key, event.Rune, err
 }
 
-func (*defaultInput) readRuneANSI() (rune, error) {
-	var buf = [1]byte{0}
-	_, err := os.Stdin.Read(buf[:])
-	if err != nil {
-		return 0, err
-	}
-	return rune(buf[0]), nil
+// ReadKeyEvent returns the next key event including held modifier keys. BeginReadKey needs to be called first.
+func (c *Console) ReadKeyEvent() (KeyEvent, error) {
+	return c.Input().ReadKeyEvent()
 }
 
-func (*defaultInput) readRuneUTF8() (rune, error) {
-	// utf8 runes can take 1 up to 4 bytes
-	var buf = [4]byte{0}
-	_, err := os.Stdin.Read(buf[0:1])
-	if err != nil {
-		return 0, err
-	}
+// ReadKeyEventContext returns a key event like ReadKeyEvent, but returns the context error as soon as ctx is done.
+func (c *Console) ReadKeyEventContext(ctx context.Context) (KeyEvent, error) {
+	return c.Input().ReadKeyEventContext(ctx)
+}
 
-	// most common case: rune takes exactly one byte
-	if !utf8.FullRune(buf[0:1]) {
-		// not complete yet? read next byte and check again
-		for i := 1; i < 4; i++ {
-			// put next byte into buffer
-			_, err := os.Stdin.Read(buf[i : i+1])
-			if err != nil {
-				return 0, err
-			}
-			if i < 3 {
-				// skip check for last rune -> will terminate either way
-				if utf8.FullRune(buf[0 : i+1]) {
-					break
-				}
-			}
-		}
+// EndReadKey restores the console input mode changed by BeginReadKey and discards all unprocessed key events.
+func (c *Console) EndReadKey() error {
+	return c.Input().EndReadKey()
+}
+
+// WithReadKeyContext executes the given function with surrounding BeginReadKey and EndReadKey calls.
+func (c *Console) WithReadKeyContext(f func() error) error {
+	input := c.Input()
+	if err := input.BeginReadKey(); err != nil {
+		return err
 	}
+	defer input.EndReadKey()
+
+	return f()
+}
+
+// Print writes a set of objects separated by whitespaces to Stdout.
+func Print(a ...interface{}) (int, error) {
+	return defaultConsole.Print(a...)
+}
+
+// Printf writes a formatted string to Stdout.
+func Printf(format string, a ...interface{}) (int, error) {
+	return defaultConsole.Printf(format, a...)
+}
+
+// Println writes a set of objects separated by whitespaces to Stdout and ends the line.
+func Println(a ...interface{}) (int, error) {
+	return defaultConsole.Println(a...)
+}
+
+// Printlnf writes a formatted string to Stdout and ends the line.
+func Printlnf(format string, a ...interface{}) (int, error) {
+	return defaultConsole.Printlnf(format, a...)
+}
+
+// Fatal calls Print and exits with code 1.
+func Fatal(a ...interface{}) {
+	defaultConsole.Fatal(a...)
+}
+
+// Fatalf calls Printf and exits with code 1.
+func Fatalf(format string, a ...interface{}) {
+	defaultConsole.Fatalf(format, a...)
+}
+
+// Fatalln calls Println and exits with code 1.
+func Fatalln(a ...interface{}) {
+	defaultConsole.Fatalln(a...)
+}
 
-	r, _ := utf8.DecodeRune(buf[:])
-	return r, nil
+// Fatallnf calls Printlnf and exits with code 1.
+func Fatallnf(format string, a ...interface{}) {
+	defaultConsole.Fatallnf(format, a...)
+}
+
+// PrintList prints all array or map values in a regular grid.
+func PrintList(obj interface{}) error {
+	return defaultConsole.PrintList(obj)
+}
+
+// GetSize returns the current terminal dimensions in characters.
+func GetSize() (int, int, error) {
+	return defaultConsole.GetSize()
+}
+
+// SupportsColors returns true when the current terminal supports ANSI colors.
+func SupportsColors() bool {
+	return defaultConsole.SupportsColors()
 }
 
 // ReadLine reads a line from Stdin.
 //
 // This method should not be used in conjunction with Stdin read from other packages as it might leave an orphaned '\n' in the input buffer for '\r\n' line breaks.
 func ReadLine() (string, error) {
-	return DefaultInput.ReadLine()
+	return defaultConsole.ReadLine()
 }
 
-func (d *defaultInput) ReadPassword() (string, error) {
-	var pw string
-	if err := withoutEcho(func() error {
-		line, err := d.ReadLine()
-		pw = line
-		return err
-	}); err != nil {
-		return "", err
-	}
-	// print suppressed line-feed
-	Println()
-	return pw, nil
+// ReadLineContext reads a line from Stdin like ReadLine, but returns the context error as soon as ctx is done.
+func ReadLineContext(ctx context.Context) (string, error) {
+	return defaultConsole.ReadLineContext(ctx)
 }
 
 // ReadPassword reads a line from Stdin while hiding the user input.
 //
 // This method should not be used in conjunction with Stdin read from other packages as it might leave an orphaned '\n' in the input buffer for '\r\n' line breaks.
 func ReadPassword() (string, error) {
-	return DefaultInput.ReadPassword()
+	return defaultConsole.ReadPassword()
 }
 
-func (d *defaultInput) BeginReadKey() error {
-	return beginReadKey()
+// BeginReadKey opens a raw TTY and allows you to use ReadKey.
+func BeginReadKey() error {
+	return defaultConsole.BeginReadKey()
 }
 
-func (d *defaultInput) ReadKey() (Key, rune, error) {
-	return readKey()
+// ReadKey returns a key and the corresponding rune or an error. BeginReadKey needs to be called first.
+func ReadKey() (Key, rune, error) {
+	return defaultConsole.ReadKey()
 }
 
-func (d *defaultInput) EndReadKey() error {
-	return endReadKey()
+// ReadKeyContext returns a key like ReadKey, but returns the context error as soon as ctx is done. BeginReadKey needs to be called first.
+func ReadKeyContext(ctx context.Context) (Key, rune, error) {
+	return defaultConsole.ReadKeyContext(ctx)
 }
 
-// BeginReadKey opens a raw TTY and allows you to use ReadKey.
-func BeginReadKey() error {
-	return DefaultInput.BeginReadKey()
+// ReadKeyEvent returns the next key event including held modifier keys. BeginReadKey needs to be called first.
+func ReadKeyEvent() (KeyEvent, error) {
+	return defaultConsole.ReadKeyEvent()
 }
 
-// ReadKey returns a key and the corresponding rune or an error. BeginReadKey needs to be called first.
-func ReadKey() (Key, rune, error) {
-	return DefaultInput.ReadKey()
+// ReadKeyEventContext returns a key event like ReadKeyEvent, but returns the context error as soon as ctx is done.
+func ReadKeyEventContext(ctx context.Context) (KeyEvent, error) {
+	return defaultConsole.ReadKeyEventContext(ctx)
 }
 
 // EndReadKey closes the raw TTY opened by BeginReadKey and discards all unprocessed key events.
 func EndReadKey() error {
-	return DefaultInput.EndReadKey()
+	return defaultConsole.EndReadKey()
 }
 
 // WithReadKeyContext executes the given function with surrounding BeginReadKey and EndReadKey calls.
 func WithReadKeyContext(f func() error) error {
-	if err := DefaultInput.BeginReadKey(); err != nil {
-		return err
-	}
-	defer DefaultInput.EndReadKey()
-
-	return f()
+	return defaultConsole.WithReadKeyContext(f)
 }
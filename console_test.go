@@ -1,7 +1,12 @@
 package console
 
 import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -48,6 +53,135 @@ func TestReadKey(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestReadKeyEvent(t *testing.T) {
+	oldInput := DefaultInput
+	defer func() {
+		DefaultInput = oldInput
+	}()
+	DefaultInput = &keyFakeInput{KeyTab, '\t', nil, false}
+
+	BeginReadKey()
+	defer EndReadKey()
+
+	event, err := ReadKeyEvent()
+	assert.NoError(t, err)
+	assert.Equal(t, KeyEvent{Key: KeyTab, Rune: '\t'}, event)
+}
+
+func TestKeyEventString(t *testing.T) {
+	assert.Equal(t, "'a'", KeyEvent{Rune: 'a'}.String())
+	assert.Equal(t, "Alt+'b'", KeyEvent{Rune: 'b', Modifiers: ModAlt}.String())
+	assert.Equal(t, "Ctrl+Shift+ArrowLeft", KeyEvent{Key: KeyLeft, Modifiers: ModCtrl | ModShift}.String())
+	assert.Equal(t, "CtrlA", KeyEvent{Key: KeyCtrlA, Modifiers: ModCtrl}.String())
+	assert.Equal(t, "Alt+CtrlR", KeyEvent{Key: KeyCtrlR, Modifiers: ModCtrl | ModAlt}.String())
+}
+
+func TestReadKeyContextCancelled(t *testing.T) {
+	oldInput := DefaultInput
+	defer func() {
+		DefaultInput = oldInput
+	}()
+	DefaultInput = &keyFakeInput{KeyEnter, '\n', nil, false}
+
+	BeginReadKey()
+	defer EndReadKey()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err := ReadKeyContext(ctx)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestNewConsoleReadLine(t *testing.T) {
+	var out bytes.Buffer
+	c := New(strings.NewReader("foo\r\nbär\nblub"), &out, nil)
+
+	line, err := c.ReadLine()
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", line)
+	line, err = c.ReadLine()
+	assert.NoError(t, err)
+	assert.Equal(t, "bär", line)
+	line, err = c.ReadLine()
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, "blub", line)
+
+	c.Printlnf("hello %s", "world")
+	assert.Equal(t, "hello world\n", out.String())
+}
+
+func TestNewConsoleReadKeyEvent(t *testing.T) {
+	c := New(strings.NewReader("a\x1b[1;5D\x1bb\x1b"), io.Discard, nil)
+	assert.NoError(t, c.BeginReadKey())
+	defer c.EndReadKey()
+
+	expected := []KeyEvent{
+		{Rune: 'a'},
+		{Key: KeyLeft, Modifiers: ModCtrl},
+		{Rune: 'b', Modifiers: ModAlt},
+		{Key: KeyEscape, Rune: '^'},
+	}
+	for _, e := range expected {
+		event, err := c.ReadKeyEvent()
+		assert.NoError(t, err)
+		assert.Equal(t, e, event)
+	}
+	_, err := c.ReadKeyEvent()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestNewConsoleReadKeyEventContext(t *testing.T) {
+	r, w := io.Pipe()
+	defer w.Close()
+	c := New(r, io.Discard, nil)
+	assert.NoError(t, c.BeginReadKey())
+	defer c.EndReadKey()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := c.ReadKeyEventContext(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	// input is not lost on cancellation
+	go w.Write([]byte("x"))
+	event, err := c.ReadKeyEvent()
+	assert.NoError(t, err)
+	assert.Equal(t, KeyEvent{Rune: 'x'}, event)
+}
+
+func TestNewConsoleOptions(t *testing.T) {
+	c := New(strings.NewReader(""), io.Discard, nil)
+	_, _, err := c.GetSize()
+	assert.Error(t, err)
+	assert.False(t, c.SupportsColors())
+
+	exitCode := -1
+	c = New(strings.NewReader(""), io.Discard, &Options{
+		GetSize:        func() (int, int, error) { return 120, 40, nil },
+		SupportsColors: true,
+		Exit:           func(code int) { exitCode = code },
+	})
+	width, height, err := c.GetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 120, width)
+	assert.Equal(t, 40, height)
+	assert.True(t, c.SupportsColors())
+	c.Fatalln("bye")
+	assert.Equal(t, 1, exitCode)
+}
+
+func TestDefaultConsoleUsesDefaultInput(t *testing.T) {
+	oldInput := DefaultInput
+	defer func() {
+		DefaultInput = oldInput
+	}()
+	DefaultInput = &keyFakeInput{KeyTab, '\t', nil, false}
+
+	assert.Equal(t, DefaultInput, Default().Input())
+	var c *Console
+	assert.Equal(t, DefaultInput, c.Input())
+}
+
 type keyFakeInput struct {
 	Key       Key
 	Rune      rune
@@ -58,6 +192,9 @@ type keyFakeInput struct {
 func (i *keyFakeInput) ReadLine() (string, error) {
 	panic("ReadLine not implemented on keyFakeInput")
 }
+func (i *keyFakeInput) ReadLineContext(context.Context) (string, error) {
+	panic("ReadLineContext not implemented on keyFakeInput")
+}
 func (i *keyFakeInput) ReadPassword() (string, error) {
 	panic("ReadLine not implemented on keyFakeInput")
 }
@@ -74,6 +211,18 @@ func (i *keyFakeInput) ReadKey() (Key, rune, error) {
 	}
 	return i.Key, i.Rune, i.Error
 }
+func (i *keyFakeInput) ReadKeyEvent() (KeyEvent, error) {
+	if !i.isReading {
+		panic("ReadKeyEvent before BeginReadKey")
+	}
+	return KeyEvent{Key: i.Key, Rune: i.Rune}, i.Error
+}
+func (i *keyFakeInput) ReadKeyEventContext(ctx context.Context) (KeyEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return KeyEvent{}, err
+	}
+	return i.ReadKeyEvent()
+}
 func (i *keyFakeInput) EndReadKey() error {
 	if !i.isReading {
 		panic("EndReadKey before BeginReadKey")
@@ -81,3 +230,8 @@ func (i *keyFakeInput) EndReadKey() error {
 	i.isReading = false
 	return nil
 }
+
+func TestTerminalInputEndWithoutBegin(t *testing.T) {
+	input := &terminalInput{}
+	assert.NoError(t, input.EndReadKey())
+}
@@ -1,7 +1,9 @@
 package consoletest
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/sbreitf1/go-console"
@@ -10,9 +12,10 @@ import (
 )
 
 type ReadKeyResult struct {
-	Key   console.Key
-	Rune  rune
-	Error error
+	Key       console.Key
+	Rune      rune
+	Error     error
+	Modifiers console.Modifiers
 }
 
 type MockInput struct {
@@ -29,23 +32,29 @@ func (m *MockInput) PutString(buffer string) {
 	for _, r := range buffer {
 		switch r {
 		case '\r':
-			m.buffer = append(m.buffer, ReadKeyResult{console.KeyBackspace, 0, nil})
+			m.buffer = append(m.buffer, ReadKeyResult{Key: console.KeyBackspace})
 		case '\n':
-			m.buffer = append(m.buffer, ReadKeyResult{console.KeyEnter, 0, nil})
+			m.buffer = append(m.buffer, ReadKeyResult{Key: console.KeyEnter})
 		case ' ':
-			m.buffer = append(m.buffer, ReadKeyResult{console.KeySpace, 0, nil})
+			m.buffer = append(m.buffer, ReadKeyResult{Key: console.KeySpace})
 		case '\t':
-			m.buffer = append(m.buffer, ReadKeyResult{console.KeyTab, 0, nil})
+			m.buffer = append(m.buffer, ReadKeyResult{Key: console.KeyTab})
 
 		default:
-			m.buffer = append(m.buffer, ReadKeyResult{0, r, nil})
+			m.buffer = append(m.buffer, ReadKeyResult{Rune: r})
 		}
 	}
 }
 
 func (m *MockInput) PutKeys(keys ...console.Key) {
 	for _, k := range keys {
-		m.buffer = append(m.buffer, ReadKeyResult{k, 0, nil})
+		m.buffer = append(m.buffer, ReadKeyResult{Key: k})
+	}
+}
+
+func (m *MockInput) PutKeyEvents(events ...console.KeyEvent) {
+	for _, e := range events {
+		m.buffer = append(m.buffer, ReadKeyResult{Key: e.Key, Rune: e.Rune, Modifiers: e.Modifiers})
 	}
 }
 
@@ -61,6 +70,10 @@ func (m *MockInput) ReadLine() (string, error) {
 	panic("ReadLine not available for mock")
 }
 
+func (m *MockInput) ReadLineContext(context.Context) (string, error) {
+	panic("ReadLineContext not available for mock")
+}
+
 func (m *MockInput) ReadPassword() (string, error) {
 	panic("ReadPassword not available for mock")
 }
@@ -74,17 +87,34 @@ func (m *MockInput) BeginReadKey() error {
 }
 
 func (m *MockInput) ReadKey() (console.Key, rune, error) {
+	event, err := m.ReadKeyEvent()
+	return event.Key, event.Rune, err
+}
+
+func (m *MockInput) ReadKeyEvent() (console.KeyEvent, error) {
 	if m.BufferConsumed() {
 		panic("too many ReadKey calls detected")
 	}
 
 	if !m.isReadKeyActive {
-		return 0, 0, fmt.Errorf("call to ReadKey before BeginReadKey")
+		return console.KeyEvent{}, fmt.Errorf("call to ReadKey before BeginReadKey")
 	}
 
 	result := m.buffer[m.bufferPos]
 	m.bufferPos++
-	return result.Key, result.Rune, result.Error
+	return console.KeyEvent{Key: result.Key, Rune: result.Rune, Modifiers: result.Modifiers}, result.Error
+}
+
+// ReadKeyEventContext returns the next buffered key event. It blocks until ctx is done when the buffer has been consumed.
+func (m *MockInput) ReadKeyEventContext(ctx context.Context) (console.KeyEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return console.KeyEvent{}, err
+	}
+	if m.BufferConsumed() && ctx.Done() != nil {
+		<-ctx.Done()
+		return console.KeyEvent{}, ctx.Err()
+	}
+	return m.ReadKeyEvent()
 }
 
 func (m *MockInput) EndReadKey() error {
@@ -95,6 +125,48 @@ func (m *MockInput) EndReadKey() error {
 	return nil
 }
 
+// MockOutput records all printed text and reports a fixed terminal size.
+type MockOutput struct {
+	sb       strings.Builder
+	Width    int
+	Height   int
+	Colors   bool
+	ExitCode int
+}
+
+func NewMockOutput() *MockOutput {
+	return &MockOutput{Width: 80, Height: 25, ExitCode: -1}
+}
+
+func (m *MockOutput) Print(str string) (int, error) {
+	return m.sb.WriteString(str)
+}
+
+func (m *MockOutput) GetSize() (int, int, error) {
+	return m.Width, m.Height, nil
+}
+
+func (m *MockOutput) SupportsColors() bool {
+	return m.Colors
+}
+
+// Exit records the exit code instead of terminating the process.
+func (m *MockOutput) Exit(code int) {
+	m.ExitCode = code
+}
+
+// String returns all text printed so far.
+func (m *MockOutput) String() string {
+	return m.sb.String()
+}
+
+// NewMockConsole returns a console with mocked input and output without touching the default console.
+func NewMockConsole() (*console.Console, *MockInput, *MockOutput) {
+	input := NewMockInput()
+	output := NewMockOutput()
+	return console.NewWithInterfaces(input, output), input, output
+}
+
 func WithMocks(f func(input *MockInput)) {
 	oldInput := console.DefaultInput
 	oldOutput := console.DefaultOutput
@@ -19,12 +19,8 @@ func main() {
 		if err != nil {
 			return ""
 		}
-		if console.SupportsColors() {
-			// display current working directory in nice colors as prompt
-			return fmt.Sprintf("\033[1;34m%s\033[0m", pwd)
-		}
-		// fallback: no colors supported
-		return pwd
+		// display current working directory in nice colors as prompt, escapes are omitted if colors are not supported
+		return console.SprintStyled(console.Style{Foreground: console.ColorBlue, Bold: true}, pwd)
 	}
 
 	cle.RegisterCommand(commandline.NewExitCommand("exit"))
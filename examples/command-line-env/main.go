@@ -11,6 +11,7 @@ func main() {
 	cle := commandline.NewEnvironment()
 
 	cle.RegisterCommand(commandline.NewExitCommand("exit"))
+	cle.RegisterCommand(commandline.NewHistoryCommand("history", cle))
 
 	cle.ExecUnknownCommand = func(cmd string, args []string) error {
 		console.Printlnf("Unknown command %q", cmd)
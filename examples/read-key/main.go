@@ -12,13 +12,13 @@ func main() {
 
 	console.Println("Press Escape to exit")
 	for {
-		key, char, err := console.ReadKey()
+		event, err := console.ReadKeyEvent()
 		if err != nil {
-			console.Fatallnf("ReadKey failed: %s", err.Error())
+			console.Fatallnf("ReadKeyEvent failed: %s", err.Error())
 		}
-		console.Printlnf("%s -> %q", key, string(char))
+		console.Printlnf("%s -> %q", event, string(event.Rune))
 
-		if key == console.KeyEscape {
+		if event.Key == console.KeyEscape {
 			break
 		}
 	}
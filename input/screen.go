@@ -5,7 +5,8 @@ import "github.com/sbreitf1/go-console"
 type screen interface {
 	Clear()
 	Size() (int, int)
-	SetCell(x, y int, r rune)
+	// SetCell sets a single grapheme cluster. Wide clusters also cover the next cell.
+	SetCell(x, y int, cluster string)
 	Flush()
 	SetCursor(x, y int)
 	PollEvent() event
@@ -19,16 +20,22 @@ type errorEvent struct {
 }
 
 type keyEvent struct {
-	Key  console.Key
-	Rune rune
+	Key       console.Key
+	Rune      rune
+	Modifiers console.Modifiers
 }
 
 type resizeEvent struct{}
 
 func printCells(screen screen, str string, x, y int) {
 	//TODO support for multiline string
-	runes := []rune(str)
-	for i := range runes {
-		screen.SetCell(x+i, y, runes[i])
+	for _, cluster := range console.Graphemes(str) {
+		screen.SetCell(x, y, cluster)
+		x += clusterWidth(cluster)
 	}
 }
+
+// clusterWidth returns the number of cells occupied by a grapheme cluster. Every cluster takes at least one cell.
+func clusterWidth(cluster string) int {
+	return max(console.StringWidth(cluster), 1)
+}
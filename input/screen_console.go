@@ -0,0 +1,107 @@
+package input
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sbreitf1/go-console"
+)
+
+// consoleScreen renders to an arbitrary console using ANSI escape sequences.
+type consoleScreen struct {
+	console       *console.Console
+	width, height int
+	// cells contains a grapheme cluster per cell. Cells covered by a preceding wide cluster are empty.
+	cells            [][]string
+	cursorX, cursorY int
+}
+
+func newConsoleScreen(c *console.Console) (screen, error) {
+	if err := c.BeginReadKey(); err != nil {
+		return nil, err
+	}
+	// switch to alternate screen buffer
+	if _, err := c.Print("\x1b[?1049h"); err != nil {
+		c.EndReadKey()
+		return nil, err
+	}
+
+	s := &consoleScreen{console: c}
+	s.Clear()
+	return s, nil
+}
+
+func (s *consoleScreen) Clear() {
+	width, height, err := s.console.GetSize()
+	if err != nil {
+		// fallback to common terminal size
+		width, height = 80, 24
+	}
+
+	s.width, s.height = width, height
+	s.cells = make([][]string, height)
+	for y := range s.cells {
+		s.cells[y] = make([]string, width)
+		for x := range s.cells[y] {
+			s.cells[y][x] = " "
+		}
+	}
+}
+func (s *consoleScreen) Size() (int, int) {
+	return s.width, s.height
+}
+func (s *consoleScreen) SetCell(x, y int, cluster string) {
+	if x < 0 || x >= s.width || y < 0 || y >= s.height {
+		return
+	}
+
+	width := console.StringWidth(cluster)
+	if width == 0 {
+		// control characters must not be printed
+		cluster, width = " ", 1
+	}
+	if x+width > s.width {
+		// wide cluster does not fit at the end of the line
+		cluster, width = " ", 1
+	}
+	row := s.cells[y]
+	// replace partially overwritten wide clusters by spaces
+	if len(row[x]) == 0 && x > 0 {
+		row[x-1] = " "
+	}
+	if end := x + width; end < s.width && len(row[end]) == 0 {
+		row[end] = " "
+	}
+
+	row[x] = cluster
+	for i := 1; i < width; i++ {
+		row[x+i] = ""
+	}
+}
+func (s *consoleScreen) Flush() {
+	var sb strings.Builder
+	// hide cursor while drawing
+	sb.WriteString("\x1b[?25l")
+	for y := range s.cells {
+		fmt.Fprintf(&sb, "\x1b[%d;1H", y+1)
+		sb.WriteString(strings.Join(s.cells[y], ""))
+	}
+	fmt.Fprintf(&sb, "\x1b[%d;%dH\x1b[?25h", s.cursorY+1, s.cursorX+1)
+	s.console.Print(sb.String())
+}
+func (s *consoleScreen) SetCursor(x, y int) {
+	s.cursorX, s.cursorY = x, y
+}
+func (s *consoleScreen) PollEvent() event {
+	e, err := s.console.ReadKeyEvent()
+	if err != nil {
+		return errorEvent{err}
+	}
+	return keyEvent(e)
+}
+
+func (s *consoleScreen) Close() {
+	// restore main screen buffer
+	s.console.Print("\x1b[?1049l")
+	s.console.EndReadKey()
+}
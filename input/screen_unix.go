@@ -14,6 +14,8 @@ func newScreen() (screen, error) {
 	if err := termbox.Init(); err != nil {
 		return nil, err
 	}
+	// report escape prefixed keys as alt modified keys, InputEsc must not be set as it takes precedence
+	termbox.SetInputMode(termbox.InputAlt)
 
 	return &unixScreen{}, nil
 }
@@ -24,8 +26,12 @@ func (s *unixScreen) Clear() {
 func (s *unixScreen) Size() (int, int) {
 	return termbox.Size()
 }
-func (s *unixScreen) SetCell(x, y int, r rune) {
-	termbox.SetCell(x, y, r, termbox.ColorDefault, termbox.ColorDefault)
+func (s *unixScreen) SetCell(x, y int, cluster string) {
+	// termbox does not support combining characters, only the base rune is displayed
+	for _, r := range cluster {
+		termbox.SetCell(x, y, r, termbox.ColorDefault, termbox.ColorDefault)
+		break
+	}
 }
 func (s *unixScreen) Flush() {
 	termbox.Flush()
@@ -39,48 +45,7 @@ func (s *unixScreen) PollEvent() event {
 		// translate received event
 		switch e := termbox.PollEvent(); e.Type {
 		case termbox.EventKey:
-			switch e.Key {
-			case termbox.KeyEsc:
-				return keyEvent{console.KeyEscape, '\000'}
-
-			case termbox.KeyCtrlW:
-				return keyEvent{console.KeyCtrlW, '\000'}
-			case termbox.KeyCtrlS:
-				return keyEvent{console.KeyCtrlS, '\000'}
-
-			case termbox.KeyArrowUp:
-				return keyEvent{console.KeyUp, '\000'}
-			case termbox.KeyArrowDown:
-				return keyEvent{console.KeyDown, '\000'}
-			case termbox.KeyArrowLeft:
-				return keyEvent{console.KeyLeft, '\000'}
-			case termbox.KeyArrowRight:
-				return keyEvent{console.KeyRight, '\000'}
-			case termbox.KeyHome:
-				return keyEvent{console.KeyHome, '\000'}
-			case termbox.KeyEnd:
-				return keyEvent{console.KeyEnd, '\000'}
-			case termbox.KeyPgup:
-				return keyEvent{console.KeyPageUp, '\000'}
-			case termbox.KeyPgdn:
-				return keyEvent{console.KeyPageDown, '\000'}
-
-			case termbox.KeyBackspace:
-				fallthrough
-			case termbox.KeyBackspace2:
-				return keyEvent{console.KeyBackspace, '\r'}
-			case termbox.KeyDelete:
-				return keyEvent{console.KeyDelete, '\000'}
-			case termbox.KeyEnter:
-				return keyEvent{console.KeyEnter, '\n'}
-			case termbox.KeySpace:
-				return keyEvent{console.KeySpace, ' '}
-			case termbox.KeyTab:
-				return keyEvent{console.KeyTab, '\t'}
-
-			default:
-				return keyEvent{0, e.Ch}
-			}
+			return translateKeyEvent(e)
 
 		case termbox.EventResize:
 			return resizeEvent{}
@@ -90,6 +55,60 @@ func (s *unixScreen) PollEvent() event {
 		}
 	}
 }
+// translateKeyEvent returns the key event including modifiers. termbox does not report Ctrl for arrow keys, so only Alt is available.
+func translateKeyEvent(e termbox.Event) keyEvent {
+	event := translateKey(e)
+	if e.Mod&termbox.ModAlt != 0 {
+		event.Modifiers |= console.ModAlt
+	}
+	return event
+}
+
+func translateKey(e termbox.Event) keyEvent {
+	switch e.Key {
+	case termbox.KeyEsc:
+		return keyEvent{Key: console.KeyEscape, Rune: '\000'}
+
+	case termbox.KeyCtrlW:
+		return keyEvent{Key: console.KeyCtrlW, Rune: '\000'}
+	case termbox.KeyCtrlS:
+		return keyEvent{Key: console.KeyCtrlS, Rune: '\000'}
+
+	case termbox.KeyArrowUp:
+		return keyEvent{Key: console.KeyUp, Rune: '\000'}
+	case termbox.KeyArrowDown:
+		return keyEvent{Key: console.KeyDown, Rune: '\000'}
+	case termbox.KeyArrowLeft:
+		return keyEvent{Key: console.KeyLeft, Rune: '\000'}
+	case termbox.KeyArrowRight:
+		return keyEvent{Key: console.KeyRight, Rune: '\000'}
+	case termbox.KeyHome:
+		return keyEvent{Key: console.KeyHome, Rune: '\000'}
+	case termbox.KeyEnd:
+		return keyEvent{Key: console.KeyEnd, Rune: '\000'}
+	case termbox.KeyPgup:
+		return keyEvent{Key: console.KeyPageUp, Rune: '\000'}
+	case termbox.KeyPgdn:
+		return keyEvent{Key: console.KeyPageDown, Rune: '\000'}
+
+	case termbox.KeyBackspace:
+		fallthrough
+	case termbox.KeyBackspace2:
+		return keyEvent{Key: console.KeyBackspace, Rune: '\r'}
+	case termbox.KeyDelete:
+		return keyEvent{Key: console.KeyDelete, Rune: '\000'}
+	case termbox.KeyEnter:
+		return keyEvent{Key: console.KeyEnter, Rune: '\n'}
+	case termbox.KeySpace:
+		return keyEvent{Key: console.KeySpace, Rune: ' '}
+	case termbox.KeyTab:
+		return keyEvent{Key: console.KeyTab, Rune: '\t'}
+
+	default:
+		return keyEvent{Key: 0, Rune: e.Ch}
+	}
+}
+
 func (s *unixScreen) Close() {
 	termbox.Close()
 }
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package input
+
+import (
+	"testing"
+
+	"github.com/sbreitf1/go-console"
+
+	"github.com/nsf/termbox-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslateKeyEvent(t *testing.T) {
+	assert.Equal(t, keyEvent{Rune: 'b', Modifiers: console.ModAlt}, translateKeyEvent(termbox.Event{Type: termbox.EventKey, Ch: 'b', Mod: termbox.ModAlt}))
+	assert.Equal(t, keyEvent{Rune: 'f', Modifiers: console.ModAlt}, translateKeyEvent(termbox.Event{Type: termbox.EventKey, Ch: 'f', Mod: termbox.ModAlt}))
+	assert.Equal(t, keyEvent{Key: console.KeyEscape}, translateKeyEvent(termbox.Event{Type: termbox.EventKey, Key: termbox.KeyEsc}))
+	assert.Equal(t, keyEvent{Rune: 'b'}, translateKeyEvent(termbox.Event{Type: termbox.EventKey, Ch: 'b'}))
+}
@@ -31,8 +31,10 @@ func (s *windowsScreen) Clear() {
 func (s *windowsScreen) Size() (int, int) {
 	return s.screen.Size()
 }
-func (s *windowsScreen) SetCell(x, y int, r rune) {
-	s.screen.SetContent(x, y, r, nil, tcell.StyleDefault)
+func (s *windowsScreen) SetCell(x, y int, cluster string) {
+	if runes := []rune(cluster); len(runes) > 0 {
+		s.screen.SetContent(x, y, runes[0], runes[1:], tcell.StyleDefault)
+	}
 }
 func (s *windowsScreen) Flush() {
 	s.screen.Sync()
@@ -46,49 +48,21 @@ func (s *windowsScreen) PollEvent() event {
 		// translate received event
 		switch e := s.screen.PollEvent().(type) {
 		case *tcell.EventKey:
-			switch e.Key() {
-			case tcell.KeyEscape:
-				return keyEvent{console.KeyEscape, '\000'}
-
-			case tcell.KeyCtrlW:
-				return keyEvent{console.KeyCtrlW, '\000'}
-			case tcell.KeyCtrlS:
-				return keyEvent{console.KeyCtrlS, '\000'}
-
-			case tcell.KeyUp:
-				return keyEvent{console.KeyUp, '\000'}
-			case tcell.KeyDown:
-				return keyEvent{console.KeyDown, '\000'}
-			case tcell.KeyLeft:
-				return keyEvent{console.KeyLeft, '\000'}
-			case tcell.KeyRight:
-				return keyEvent{console.KeyRight, '\000'}
-			case tcell.KeyHome:
-				return keyEvent{console.KeyHome, '\000'}
-			case tcell.KeyEnd:
-				return keyEvent{console.KeyEnd, '\000'}
-			case tcell.KeyPgUp:
-				return keyEvent{console.KeyPageUp, '\000'}
-			case tcell.KeyPgDn:
-				return keyEvent{console.KeyPageDown, '\000'}
-
-			case tcell.KeyBackspace:
-				fallthrough
-			case tcell.KeyBackspace2:
-				return keyEvent{console.KeyBackspace, '\r'}
-			case tcell.KeyDelete:
-				return keyEvent{console.KeyDelete, '\000'}
-			case tcell.KeyEnter:
-				return keyEvent{console.KeyEnter, '\n'}
-			case tcell.KeyTab:
-				return keyEvent{console.KeyTab, '\t'}
-
-			default:
-				if e.Rune() == ' ' {
-					return keyEvent{console.KeySpace, ' '}
-				}
-				return keyEvent{0, e.Rune()}
+			event := translateKey(e)
+			mods := e.Modifiers()
+			if mods&tcell.ModShift != 0 {
+				event.Modifiers |= console.ModShift
+			}
+			if mods&tcell.ModAlt != 0 {
+				event.Modifiers |= console.ModAlt
+			}
+			if mods&tcell.ModCtrl != 0 {
+				event.Modifiers |= console.ModCtrl
+			}
+			if mods&tcell.ModMeta != 0 {
+				event.Modifiers |= console.ModMeta
 			}
+			return event
 
 		case *tcell.EventResize:
 			return resizeEvent{}
@@ -98,6 +72,52 @@ func (s *windowsScreen) PollEvent() event {
 		}
 	}
 }
+func translateKey(e *tcell.EventKey) keyEvent {
+	switch e.Key() {
+	case tcell.KeyEscape:
+		return keyEvent{Key: console.KeyEscape, Rune: '\000'}
+
+	case tcell.KeyCtrlW:
+		return keyEvent{Key: console.KeyCtrlW, Rune: '\000'}
+	case tcell.KeyCtrlS:
+		return keyEvent{Key: console.KeyCtrlS, Rune: '\000'}
+
+	case tcell.KeyUp:
+		return keyEvent{Key: console.KeyUp, Rune: '\000'}
+	case tcell.KeyDown:
+		return keyEvent{Key: console.KeyDown, Rune: '\000'}
+	case tcell.KeyLeft:
+		return keyEvent{Key: console.KeyLeft, Rune: '\000'}
+	case tcell.KeyRight:
+		return keyEvent{Key: console.KeyRight, Rune: '\000'}
+	case tcell.KeyHome:
+		return keyEvent{Key: console.KeyHome, Rune: '\000'}
+	case tcell.KeyEnd:
+		return keyEvent{Key: console.KeyEnd, Rune: '\000'}
+	case tcell.KeyPgUp:
+		return keyEvent{Key: console.KeyPageUp, Rune: '\000'}
+	case tcell.KeyPgDn:
+		return keyEvent{Key: console.KeyPageDown, Rune: '\000'}
+
+	case tcell.KeyBackspace:
+		fallthrough
+	case tcell.KeyBackspace2:
+		return keyEvent{Key: console.KeyBackspace, Rune: '\r'}
+	case tcell.KeyDelete:
+		return keyEvent{Key: console.KeyDelete, Rune: '\000'}
+	case tcell.KeyEnter:
+		return keyEvent{Key: console.KeyEnter, Rune: '\n'}
+	case tcell.KeyTab:
+		return keyEvent{Key: console.KeyTab, Rune: '\t'}
+
+	default:
+		if e.Rune() == ' ' {
+			return keyEvent{Key: console.KeySpace, Rune: ' '}
+		}
+		return keyEvent{Key: 0, Rune: e.Rune()}
+	}
+}
+
 func (s *windowsScreen) Close() {
 	s.screen.Fini()
 }
@@ -2,6 +2,7 @@ package input
 
 import (
 	"strings"
+	"unicode"
 
 	"github.com/sbreitf1/go-console"
 )
@@ -60,26 +61,33 @@ func (e *textEditor) LineRange(start, count int) []string {
 	return strLines
 }
 
+// CaretColumn returns the display column of the caret in its line.
+func (e *textEditor) CaretColumn() int {
+	caretLine, caretPos := e.Caret()
+	return runesWidth(e.lines[caretLine][:caretPos])
+}
+
 func (e *textEditor) MoveCaretLeft() bool {
 	// check if caret in bounds. first check here to keep caret pos on short lines during vertical navigation
 	if e.caretLine < len(e.lines) && e.caretPos >= len(e.lines[e.caretLine]) {
 		e.caretPos = len(e.lines[e.caretLine])
 	}
 
-	e.caretPos--
-	if e.caretPos < 0 {
-		if e.caretLine <= 0 {
-			e.caretPos = 0
-		} else {
-			e.caretLine--
-			e.caretPos = len(e.lines[e.caretLine])
-		}
+	if e.caretPos > 0 {
+		e.caretPos = clusterBefore(e.lines[e.caretLine], e.caretPos)
+	} else if e.caretLine > 0 {
+		e.caretLine--
+		e.caretPos = len(e.lines[e.caretLine])
 	}
 	return true
 }
 
 func (e *textEditor) MoveCaretRight() bool {
-	e.caretPos++
+	if line := e.lines[e.caretLine]; e.caretPos < len(line) {
+		e.caretPos = clusterAfter(line, e.caretPos)
+	} else {
+		e.caretPos++
+	}
 	if e.caretPos >= len(e.lines[e.caretLine]) {
 		if e.caretLine >= (len(e.lines) - 1) {
 			e.caretPos = len(e.lines[e.caretLine])
@@ -91,24 +99,93 @@ func (e *textEditor) MoveCaretRight() bool {
 	return true
 }
 
+// MoveCaretWordLeft moves the caret to the beginning of the current or previous word. Line breaks are skipped like whitespace.
+func (e *textEditor) MoveCaretWordLeft() bool {
+	e.caretLine, e.caretPos = e.Caret()
+	for e.caretPos == 0 || !isWordRune(e.lines[e.caretLine][e.caretPos-1]) {
+		if e.caretPos > 0 {
+			e.caretPos--
+		} else if e.caretLine > 0 {
+			e.caretLine--
+			e.caretPos = len(e.lines[e.caretLine])
+		} else {
+			return true
+		}
+	}
+	for e.caretPos > 0 && isWordRune(e.lines[e.caretLine][e.caretPos-1]) {
+		e.caretPos--
+	}
+	return true
+}
+
+// MoveCaretWordRight moves the caret to the end of the current or next word. Line breaks are skipped like whitespace.
+func (e *textEditor) MoveCaretWordRight() bool {
+	e.caretLine, e.caretPos = e.Caret()
+	for e.caretPos >= len(e.lines[e.caretLine]) || !isWordRune(e.lines[e.caretLine][e.caretPos]) {
+		if e.caretPos < len(e.lines[e.caretLine]) {
+			e.caretPos++
+		} else if e.caretLine < len(e.lines)-1 {
+			e.caretLine++
+			e.caretPos = 0
+		} else {
+			return true
+		}
+	}
+	for e.caretPos < len(e.lines[e.caretLine]) && isWordRune(e.lines[e.caretLine][e.caretPos]) {
+		e.caretPos++
+	}
+	return true
+}
+
 func (e *textEditor) MoveCaretUp(delta int) bool {
+	column := e.column()
 	e.caretLine -= delta
 	if e.caretLine < 0 {
 		e.caretLine = 0
 		//e.caretPos = 0
 	}
+	e.caretPos = e.posAtColumn(column)
 	return true
 }
 
 func (e *textEditor) MoveCaretDown(delta int) bool {
+	column := e.column()
 	e.caretLine += delta
 	if e.caretLine >= len(e.lines) {
 		e.caretLine = len(e.lines) - 1
 		//e.caretPos = len(e.lines[e.caretLine])
 	}
+	e.caretPos = e.posAtColumn(column)
 	return true
 }
 
+// column returns the display column of the caret for vertical navigation.
+func (e *textEditor) column() int {
+	line := e.lines[e.caretLine]
+	if e.caretPos >= len(line) {
+		return runesWidth(line) + e.caretPos - len(line)
+	}
+	return runesWidth(line[:e.caretPos])
+}
+
+// posAtColumn returns the caret position in the current line that is displayed at column or left of it.
+func (e *textEditor) posAtColumn(column int) int {
+	line := e.lines[e.caretLine]
+	if width := runesWidth(line); column >= width {
+		return len(line) + column - width
+	}
+	pos, width := 0, 0
+	for pos < len(line) {
+		next := clusterAfter(line, pos)
+		width += runesWidth(line[pos:next])
+		if width > column {
+			break
+		}
+		pos = next
+	}
+	return pos
+}
+
 func (e *textEditor) MoveCaretToLineBegin() bool {
 	e.caretPos = 0
 	return true
@@ -170,10 +247,11 @@ func (e *textEditor) RemoveLeftOfCaret() bool {
 
 	if caretPos > 0 {
 		// removing only in current line
-		prefix := string(e.lines[caretLine][:caretPos-1])
+		start := clusterBefore(e.lines[caretLine], caretPos)
+		prefix := string(e.lines[caretLine][:start])
 		suffix := string(e.lines[caretLine][caretPos:])
 		e.lines[caretLine] = []rune(prefix + suffix)
-		e.caretPos = caretPos - 1
+		e.caretPos = start
 		return true
 	}
 	if caretPos == 0 && caretLine > 0 {
@@ -205,7 +283,7 @@ func (e *textEditor) RemoveRightOfCaret() bool {
 	if caretPos < len(e.lines[caretLine]) {
 		// removing only in current line
 		prefix := string(e.lines[caretLine][:caretPos])
-		suffix := string(e.lines[caretLine][caretPos+1:])
+		suffix := string(e.lines[caretLine][clusterAfter(e.lines[caretLine], caretPos):])
 		e.lines[caretLine] = []rune(prefix + suffix)
 		return true
 	}
@@ -224,6 +302,39 @@ func (e *textEditor) RemoveRightOfCaret() bool {
 	return false
 }
 
+// runesWidth returns the number of cells needed to display runes.
+func runesWidth(runes []rune) int {
+	width := 0
+	for _, cluster := range console.Graphemes(string(runes)) {
+		width += clusterWidth(cluster)
+	}
+	return width
+}
+
+// clusterBefore returns the start of the grapheme cluster left of pos.
+func clusterBefore(runes []rune, pos int) int {
+	start := 0
+	for _, cluster := range console.Graphemes(string(runes[:pos])) {
+		if next := start + len([]rune(cluster)); next < pos {
+			start = next
+		}
+	}
+	return start
+}
+
+// clusterAfter returns the end of the grapheme cluster right of pos.
+func clusterAfter(runes []rune, pos int) int {
+	clusters := console.Graphemes(string(runes[pos:]))
+	if len(clusters) == 0 {
+		return pos
+	}
+	return pos + len([]rune(clusters[0]))
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
 func max(values ...int) int {
 	maxVal := values[0]
 	for i := 1; i < len(values); i++ {
@@ -256,6 +367,15 @@ func boundBy(val int, min, max int) int {
 
 // Text opens a fullscreen text editor in console mode and returns the entered string.
 func Text(str string) (string, bool, error) {
+	return text(newScreen, str)
+}
+
+// TextWithConsole opens a fullscreen text editor like Text on the given console.
+func TextWithConsole(c *console.Console, str string) (string, bool, error) {
+	return text(func() (screen, error) { return newConsoleScreen(c) }, str)
+}
+
+func text(newScreen func() (screen, error), str string) (string, bool, error) {
 	screen, err := newScreen()
 	if err != nil {
 		return "", false, err
@@ -266,7 +386,7 @@ func Text(str string) (string, bool, error) {
 
 	// currently visible rectangle
 	firstLine := 0
-	firstPos := 0
+	firstColumn := 0
 
 	for {
 		// render current editor view
@@ -281,19 +401,20 @@ func Text(str string) (string, bool, error) {
 
 		// draw outer box
 		for x := editorOffsetX; x < (editorOffsetX + editorWidth); x++ {
-			screen.SetCell(x, editorOffsetY-1, '─')
-			screen.SetCell(x, editorOffsetY+editorHeight, '─')
+			screen.SetCell(x, editorOffsetY-1, "─")
+			screen.SetCell(x, editorOffsetY+editorHeight, "─")
 		}
 		for y := editorOffsetY; y < (editorOffsetY + editorHeight); y++ {
-			screen.SetCell(editorOffsetX-1, y, '│')
-			screen.SetCell(editorOffsetX+editorWidth, y, '│')
+			screen.SetCell(editorOffsetX-1, y, "│")
+			screen.SetCell(editorOffsetX+editorWidth, y, "│")
 		}
-		screen.SetCell(editorOffsetX-1, editorOffsetY-1, '┌')
-		screen.SetCell(editorOffsetX+editorWidth, editorOffsetY-1, '┐')
-		screen.SetCell(editorOffsetX-1, editorOffsetY+editorHeight, '└')
-		screen.SetCell(editorOffsetX+editorWidth, editorOffsetY+editorHeight, '┘')
+		screen.SetCell(editorOffsetX-1, editorOffsetY-1, "┌")
+		screen.SetCell(editorOffsetX+editorWidth, editorOffsetY-1, "┐")
+		screen.SetCell(editorOffsetX-1, editorOffsetY+editorHeight, "└")
+		screen.SetCell(editorOffsetX+editorWidth, editorOffsetY+editorHeight, "┘")
 
-		caretLine, caretPos := editor.Caret()
+		caretLine, _ := editor.Caret()
+		caretColumn := editor.CaretColumn()
 		// ensure caret is visible
 		if caretLine < firstLine {
 			firstLine = caretLine
@@ -301,20 +422,28 @@ func Text(str string) (string, bool, error) {
 		if caretLine >= (firstLine + editorHeight - 1) {
 			firstLine = caretLine - editorHeight + 1
 		}
-		if caretPos < firstPos {
-			firstPos = caretPos
+		if caretColumn < firstColumn {
+			firstColumn = caretColumn
 		}
-		if caretPos >= (firstPos + editorWidth - 1) {
-			firstPos = caretPos - editorWidth + 1
+		if caretColumn >= (firstColumn + editorWidth - 1) {
+			firstColumn = caretColumn - editorWidth + 1
 		}
 		// set relative caret location
-		screen.SetCursor(editorOffsetX+caretPos-firstPos, editorOffsetY+caretLine-firstLine)
+		screen.SetCursor(editorOffsetX+caretColumn-firstColumn, editorOffsetY+caretLine-firstLine)
 
 		// print text
 		for i, line := range editor.LineRange(firstLine, editorHeight) {
-			runes := []rune(line)
-			for j := firstPos; j < min(len(runes), firstPos+editorWidth); j++ {
-				screen.SetCell(editorOffsetX+j-firstPos, editorOffsetY+i, runes[j])
+			column := 0
+			for _, cluster := range console.Graphemes(line) {
+				width := clusterWidth(cluster)
+				if column >= firstColumn+editorWidth {
+					break
+				}
+				// wide clusters are only printed when completely visible
+				if column >= firstColumn && column+width <= firstColumn+editorWidth {
+					screen.SetCell(editorOffsetX+column-firstColumn, editorOffsetY+i, cluster)
+				}
+				column += width
 			}
 		}
 
@@ -326,45 +455,53 @@ func Text(str string) (string, bool, error) {
 
 		switch e := screen.PollEvent().(type) {
 		case keyEvent:
-			switch e.Key {
-			case console.KeyEscape:
+			key := console.KeyEvent(e)
+			switch {
+			case key.Is(console.KeyLeft, console.ModCtrl), key.IsRune('b', console.ModAlt):
+				editor.MoveCaretWordLeft()
+			case key.Is(console.KeyRight, console.ModCtrl), key.IsRune('f', console.ModAlt):
+				editor.MoveCaretWordRight()
+			case key.Modifiers.Has(console.ModAlt):
+				// ignore unknown alt combinations instead of inserting the character
+
+			case e.Key == console.KeyEscape:
 				return str, false, nil
 
-			case console.KeyCtrlW:
+			case e.Key == console.KeyCtrlW:
 				// for all nano fans :)
 				fallthrough
-			case console.KeyCtrlS:
+			case e.Key == console.KeyCtrlS:
 				return editor.String(), true, nil
 
-			case console.KeyLeft:
+			case e.Key == console.KeyLeft:
 				editor.MoveCaretLeft()
-			case console.KeyRight:
+			case e.Key == console.KeyRight:
 				editor.MoveCaretRight()
-			case console.KeyUp:
+			case e.Key == console.KeyUp:
 				editor.MoveCaretUp(1)
-			case console.KeyDown:
+			case e.Key == console.KeyDown:
 				editor.MoveCaretDown(1)
 
-			case console.KeyPageUp:
+			case e.Key == console.KeyPageUp:
 				editor.MoveCaretUp(editorHeight)
-			case console.KeyPageDown:
+			case e.Key == console.KeyPageDown:
 				editor.MoveCaretDown(editorHeight)
 
-			case console.KeyHome:
+			case e.Key == console.KeyHome:
 				editor.MoveCaretToLineBegin()
-			case console.KeyEnd:
+			case e.Key == console.KeyEnd:
 				editor.MoveCaretToLineEnd()
 
-			case console.KeyBackspace:
+			case e.Key == console.KeyBackspace:
 				editor.RemoveLeftOfCaret()
-			case console.KeyDelete:
+			case e.Key == console.KeyDelete:
 				editor.RemoveRightOfCaret()
 
-			case console.KeyEnter:
+			case e.Key == console.KeyEnter:
 				editor.NewLineAtCaret()
-			case console.KeySpace:
+			case e.Key == console.KeySpace:
 				editor.InsertAtCaret(" ")
-			case console.KeyTab:
+			case e.Key == console.KeyTab:
 				editor.InsertAtCaret("    ")
 			default:
 				if e.Rune != '\000' {
@@ -1,8 +1,12 @@
 package input
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/sbreitf1/go-console"
+	"github.com/sbreitf1/go-console/consoletest"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -88,3 +92,98 @@ func assertCaret(t *testing.T, expectedLine, expectedPos int, e *textEditor) boo
 	}
 	return false
 }
+
+func TestMoveCaretWordwise(t *testing.T) {
+	e := newTextEditor("foo bar\n  blub")
+	e.MoveCaretWordRight()
+	assertCaret(t, 0, 3, e)
+	e.MoveCaretWordRight()
+	assertCaret(t, 0, 7, e)
+	e.MoveCaretWordRight()
+	assertCaret(t, 1, 6, e)
+	e.MoveCaretWordRight()
+	assertCaret(t, 1, 6, e)
+	e.MoveCaretWordLeft()
+	assertCaret(t, 1, 2, e)
+	e.MoveCaretWordLeft()
+	assertCaret(t, 0, 4, e)
+	e.MoveCaretWordLeft()
+	assertCaret(t, 0, 0, e)
+	e.MoveCaretWordLeft()
+	assertCaret(t, 0, 0, e)
+}
+
+func TestTextWithConsole(t *testing.T) {
+	c, input, output := consoletest.NewMockConsole()
+	input.PutString("foo\nbar")
+	input.PutKeys(console.KeyCtrlS)
+
+	str, ok, err := TextWithConsole(c, "")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "foo\nbar", str)
+	input.AssertBufferConsumed(t)
+	assert.True(t, strings.HasPrefix(output.String(), "\x1b[?1049h"))
+	assert.True(t, strings.HasSuffix(output.String(), "\x1b[?1049l"))
+}
+
+func TestTextWithConsoleWordwise(t *testing.T) {
+	c, input, _ := consoletest.NewMockConsole()
+	input.PutString("foo bar")
+	input.PutKeyEvents(console.KeyEvent{Rune: 'b', Modifiers: console.ModAlt})
+	input.PutString("x")
+	input.PutKeyEvents(console.KeyEvent{Rune: 'f', Modifiers: console.ModAlt})
+	input.PutString("y")
+	input.PutKeys(console.KeyCtrlS)
+
+	str, ok, err := TextWithConsole(c, "")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "foo xbary", str)
+	input.AssertBufferConsumed(t)
+}
+
+func TestEditorGraphemeClusters(t *testing.T) {
+	// decomposed e with acute accent and CJK characters
+	e := newTextEditor("ae\u0301x\n日本語")
+	e.MoveCaretRight()
+	assertCaret(t, 0, 1, e)
+	e.MoveCaretRight()
+	assertCaret(t, 0, 3, e)
+	assert.Equal(t, 2, e.CaretColumn())
+
+	// vertical navigation keeps the display column
+	e.MoveCaretDown(1)
+	assertCaret(t, 1, 1, e)
+	assert.Equal(t, 2, e.CaretColumn())
+	e.MoveCaretRight()
+	e.MoveCaretUp(1)
+	assertCaret(t, 0, 4, e)
+
+	e.MoveCaretLeft()
+	assert.True(t, e.RemoveLeftOfCaret())
+	assert.Equal(t, "ax\n日本語", e.String())
+	assertCaret(t, 0, 1, e)
+	// column inside a wide character moves the caret before it
+	e.MoveCaretDown(1)
+	assertCaret(t, 1, 0, e)
+	assert.True(t, e.RemoveRightOfCaret())
+	assert.Equal(t, "ax\n本語", e.String())
+}
+
+func TestConsoleScreenWideCells(t *testing.T) {
+	c, _, output := consoletest.NewMockConsole()
+	output.Width, output.Height = 5, 1
+	s, err := newConsoleScreen(c)
+	assert.NoError(t, err)
+
+	s.SetCell(0, 0, "日")
+	s.SetCell(2, 0, "e\u0301")
+	// wide cluster does not fit into the last cell
+	s.SetCell(4, 0, "本")
+	assert.Equal(t, []string{"日", "", "e\u0301", " ", " "}, s.(*consoleScreen).cells[0])
+
+	// partially overwritten wide cluster is removed
+	s.SetCell(1, 0, "x")
+	assert.Equal(t, []string{" ", "x", "e\u0301", " ", " "}, s.(*consoleScreen).cells[0])
+}
@@ -0,0 +1,116 @@
+//go:build !windows
+
+package console
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// interruptPipe is a self-pipe that becomes readable as soon as the context is done. A nil *interruptPipe is never interrupted.
+type interruptPipe struct {
+	ctx  context.Context
+	r, w *os.File
+	stop chan struct{}
+}
+
+func newInterruptPipe(ctx context.Context) (*interruptPipe, error) {
+	if ctx.Done() == nil {
+		// context can never be cancelled
+		return nil, nil
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &interruptPipe{ctx, r, w, make(chan struct{})}
+	go func() {
+		select {
+		case <-ctx.Done():
+			p.w.Write([]byte{0})
+		case <-p.stop:
+		}
+	}()
+	return p, nil
+}
+
+// Close stops watching the context and releases the pipe.
+func (p *interruptPipe) Close() {
+	if p == nil {
+		return
+	}
+	close(p.stop)
+	p.r.Close()
+	p.w.Close()
+}
+
+// WaitForInput returns true when data is available to read from f within the given timeout, or the context error when it is done before.
+func (p *interruptPipe) WaitForInput(f *os.File, timeout time.Duration) (bool, error) {
+	fd := int(f.Fd())
+	pipeFd := -1
+	if p != nil {
+		pipeFd = int(p.r.Fd())
+	}
+
+	fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+	if pipeFd >= 0 {
+		fds = append(fds, unix.PollFd{Fd: int32(pipeFd), Events: unix.POLLIN})
+	}
+	pollTimeout := -1
+	if timeout >= 0 {
+		// round up to not return before the timeout has passed
+		pollTimeout = int((timeout + time.Millisecond - 1) / time.Millisecond)
+	}
+
+	for {
+		if p != nil {
+			if err := p.ctx.Err(); err != nil {
+				return false, err
+			}
+		}
+
+		// poll is used instead of select, which is limited to descriptors below FD_SETSIZE
+		n, err := unix.Poll(fds, pollTimeout)
+		if err == unix.EINTR {
+			// interrupted by signal, e.g. on terminal resize
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		if pipeFd >= 0 && fds[1].Revents != 0 {
+			return false, p.ctx.Err()
+		}
+		return n > 0 && fds[0].Revents != 0, nil
+	}
+}
+
+// contextReader reads from a file until the context is done.
+type contextReader struct {
+	f         *os.File
+	interrupt *interruptPipe
+}
+
+func newContextReader(ctx context.Context, f *os.File) (*contextReader, error) {
+	interrupt, err := newInterruptPipe(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &contextReader{f, interrupt}, nil
+}
+
+func (r *contextReader) Read(buf []byte) (int, error) {
+	if _, err := r.interrupt.WaitForInput(r.f, -1); err != nil {
+		return 0, err
+	}
+	return r.f.Read(buf)
+}
+
+func (r *contextReader) Close() {
+	r.interrupt.Close()
+}
@@ -0,0 +1,113 @@
+//go:build !windows
+
+package console
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestInterruptPipeWaitForInput(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+	defer w.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupt, err := newInterruptPipe(ctx)
+	require.NoError(t, err)
+	defer interrupt.Close()
+
+	ready, err := interrupt.WaitForInput(r, 10*time.Millisecond)
+	assert.NoError(t, err)
+	assert.False(t, ready)
+
+	w.Write([]byte("x"))
+	ready, err = interrupt.WaitForInput(r, -1)
+	assert.NoError(t, err)
+	assert.True(t, ready)
+}
+
+func TestInterruptPipeHighDescriptor(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+	defer w.Close()
+
+	// descriptors above FD_SETSIZE must not be limited by select
+	const highFd = 1500
+	if err := unix.Dup2(int(r.Fd()), highFd); err != nil {
+		t.Skipf("cannot open descriptor %d: %v", highFd, err)
+	}
+	high := os.NewFile(highFd, "high")
+	defer high.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupt, err := newInterruptPipe(ctx)
+	require.NoError(t, err)
+	defer interrupt.Close()
+
+	w.Write([]byte("x"))
+	ready, err := interrupt.WaitForInput(high, -1)
+	assert.NoError(t, err)
+	assert.True(t, ready)
+}
+
+func TestInterruptPipeCancel(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+	defer w.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	interrupt, err := newInterruptPipe(ctx)
+	require.NoError(t, err)
+	defer interrupt.Close()
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	// would block forever without cancellation
+	ready, err := interrupt.WaitForInput(r, -1)
+	assert.Equal(t, context.Canceled, err)
+	assert.False(t, ready)
+}
+
+func TestReadLineContext(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+	defer w.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	reader, err := newContextReader(ctx, r)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	d := &lineReader{}
+	readLine := func() (string, error) {
+		return d.readLine(func() (rune, error) { return readRuneUTF8(reader) })
+	}
+
+	w.Write([]byte("foo bär\n"))
+	line, err := readLine()
+	assert.NoError(t, err)
+	assert.Equal(t, "foo bär", line)
+
+	// no further input until deadline
+	w.Write([]byte("incomplete"))
+	line, err = readLine()
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, "incomplete", line)
+}
@@ -0,0 +1,294 @@
+package console
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// escapeSequenceTimeout denotes how long to wait for the remainder of an incomplete escape sequence.
+const escapeSequenceTimeout = 50 * time.Millisecond
+
+var (
+	// csiKeys maps the final byte of CSI sequences (ESC [ x) to keys.
+	csiKeys = map[byte]KeyEvent{
+		'A': {Key: KeyUp},
+		'B': {Key: KeyDown},
+		'C': {Key: KeyRight},
+		'D': {Key: KeyLeft},
+		'H': {Key: KeyHome},
+		'F': {Key: KeyEnd},
+		'P': {Key: KeyF1},
+		'Q': {Key: KeyF2},
+		'R': {Key: KeyF3},
+		'S': {Key: KeyF4},
+		'Z': {Key: KeyTab, Rune: '\t', Modifiers: ModShift},
+		// rxvt shifted arrow keys
+		'a': {Key: KeyUp, Modifiers: ModShift},
+		'b': {Key: KeyDown, Modifiers: ModShift},
+		'c': {Key: KeyRight, Modifiers: ModShift},
+		'd': {Key: KeyLeft, Modifiers: ModShift},
+	}
+
+	// csiTildeKeys maps the numeric parameter of ~-terminated CSI sequences (ESC [ n ~) to keys.
+	csiTildeKeys = map[int]Key{
+		1:  KeyHome,
+		2:  KeyInsert,
+		3:  KeyDelete,
+		4:  KeyEnd,
+		5:  KeyPageUp,
+		6:  KeyPageDown,
+		7:  KeyHome,
+		8:  KeyEnd,
+		11: KeyF1,
+		12: KeyF2,
+		13: KeyF3,
+		14: KeyF4,
+		15: KeyF5,
+		17: KeyF6,
+		18: KeyF7,
+		19: KeyF8,
+		20: KeyF9,
+		21: KeyF10,
+		23: KeyF11,
+		24: KeyF12,
+	}
+
+	// rxvtTildeModifiers maps the final byte of rxvt ~-style sequences to the encoded modifiers.
+	rxvtTildeModifiers = map[byte]Modifiers{
+		'~': 0,
+		'$': ModShift,
+		'^': ModCtrl,
+		'@': ModCtrl | ModShift,
+	}
+
+	// ss3Keys maps the final byte of SS3 sequences (ESC O x) to keys.
+	ss3Keys = map[byte]KeyEvent{
+		'A': {Key: KeyUp},
+		'B': {Key: KeyDown},
+		'C': {Key: KeyRight},
+		'D': {Key: KeyLeft},
+		'H': {Key: KeyHome},
+		'F': {Key: KeyEnd},
+		'P': {Key: KeyF1},
+		'Q': {Key: KeyF2},
+		'R': {Key: KeyF3},
+		'S': {Key: KeyF4},
+		// rxvt control arrow keys
+		'a': {Key: KeyUp, Modifiers: ModCtrl},
+		'b': {Key: KeyDown, Modifiers: ModCtrl},
+		'c': {Key: KeyRight, Modifiers: ModCtrl},
+		'd': {Key: KeyLeft, Modifiers: ModCtrl},
+	}
+
+	// linuxConsoleKeys maps the final byte of linux console function key sequences (ESC [ [ x) to keys.
+	linuxConsoleKeys = map[byte]Key{
+		'A': KeyF1,
+		'B': KeyF2,
+		'C': KeyF3,
+		'D': KeyF4,
+		'E': KeyF5,
+	}
+)
+
+// keyDecoder translates raw terminal input that can be fed in arbitrary chunks to key events.
+type keyDecoder struct {
+	buf []byte
+}
+
+// Reset discards all buffered input.
+func (d *keyDecoder) Reset() {
+	d.buf = nil
+}
+
+// Feed appends raw input to the buffer.
+func (d *keyDecoder) Feed(data []byte) {
+	d.buf = append(d.buf, data...)
+}
+
+// Pending returns true when buffered input is left.
+func (d *keyDecoder) Pending() bool {
+	return len(d.buf) > 0
+}
+
+// Next decodes the next key event from the buffer. Set flush to also decode incomplete sequences.
+func (d *keyDecoder) Next(flush bool) (event KeyEvent, ok bool) {
+	for len(d.buf) > 0 {
+		event, n, known := decodeKey(d.buf, flush)
+		if n == 0 {
+			return KeyEvent{}, false
+		}
+		d.buf = d.buf[n:]
+		if known {
+			return event, true
+		}
+		// silently drop unknown sequences
+	}
+	return KeyEvent{}, false
+}
+
+// decodeKey decodes the first key event in buf and returns the number of bytes consumed, or 0 for incomplete input.
+func decodeKey(buf []byte, flush bool) (event KeyEvent, n int, known bool) {
+	if buf[0] == 27 {
+		event, n, known = decodeEscapeSequence(buf)
+		if n == 0 && flush {
+			// take the escape byte as escape key, all remaining bytes are decoded separately
+			return KeyEvent{Key: KeyEscape, Rune: '^'}, 1, true
+		}
+		return event, n, known
+	}
+
+	// handle some special chars
+	switch buf[0] {
+	case '\r':
+		return KeyEvent{Key: KeyEnter, Rune: '\n'}, 1, true
+	case '\u007f', '\b':
+		return KeyEvent{Key: KeyBackspace, Rune: '\r'}, 1, true
+	case '\t':
+		return KeyEvent{Key: KeyTab, Rune: '\t'}, 1, true
+	case ' ':
+		return KeyEvent{Key: KeySpace, Rune: ' '}, 1, true
+	case 0:
+		return KeyEvent{Key: KeySpace, Rune: ' ', Modifiers: ModCtrl}, 1, true
+	}
+	if buf[0] < 0x20 {
+		// remaining control characters are entered using the control key
+		return KeyEvent{Key: Key(buf[0]), Modifiers: ModCtrl}, 1, true
+	}
+
+	if !utf8.FullRune(buf) {
+		if !flush {
+			return KeyEvent{}, 0, false
+		}
+		return KeyEvent{Rune: utf8.RuneError}, len(buf), true
+	}
+
+	r, n := utf8.DecodeRune(buf)
+	return KeyEvent{Rune: r}, n, true
+}
+
+func decodeEscapeSequence(buf []byte) (event KeyEvent, n int, known bool) {
+	if len(buf) < 2 {
+		return KeyEvent{}, 0, false
+	}
+
+	switch buf[1] {
+	case '[':
+		return decodeCSI(buf)
+
+	case 'O':
+		return decodeSS3(buf)
+
+	case 27:
+		if len(buf) < 3 {
+			return KeyEvent{}, 0, false
+		}
+		if buf[2] != '[' && buf[2] != 'O' {
+			// two separate escape key presses
+			return KeyEvent{Key: KeyEscape, Rune: '^'}, 1, true
+		}
+		// some terminals send alt-modified special keys with an additional escape prefix
+		event, n, known := decodeEscapeSequence(buf[1:])
+		if n == 0 {
+			return KeyEvent{}, 0, false
+		}
+		event.Modifiers |= ModAlt
+		return event, n + 1, known
+
+	default:
+		// escape prefix denotes a key pressed with alt
+		event, n, known := decodeKey(buf[1:], false)
+		if n == 0 {
+			return KeyEvent{}, 0, false
+		}
+		event.Modifiers |= ModAlt
+		return event, n + 1, known
+	}
+}
+
+func decodeCSI(buf []byte) (event KeyEvent, n int, known bool) {
+	if len(buf) < 3 {
+		return KeyEvent{}, 0, false
+	}
+
+	if buf[2] == '[' {
+		if len(buf) < 4 {
+			return KeyEvent{}, 0, false
+		}
+		key, known := linuxConsoleKeys[buf[3]]
+		return KeyEvent{Key: key}, 4, known
+	}
+
+	final, params, n := scanSequence(buf, 2)
+	if n == 0 {
+		return KeyEvent{}, 0, false
+	}
+
+	if mods, ok := rxvtTildeModifiers[final]; ok {
+		// ESC [ n ; m ~ for xterm, rxvt additionally encodes modifiers in the final byte
+		if len(params) == 0 {
+			return KeyEvent{}, n, false
+		}
+		key, known := csiTildeKeys[params[0]]
+		if len(params) > 1 {
+			mods |= parseModifierParam(params[1])
+		}
+		return KeyEvent{Key: key, Modifiers: mods}, n, known
+	}
+
+	// ESC [ 1 ; m x for modified keys in xterm
+	event, known = csiKeys[final]
+	if len(params) > 1 {
+		event.Modifiers |= parseModifierParam(params[1])
+	}
+	return event, n, known
+}
+
+func decodeSS3(buf []byte) (event KeyEvent, n int, known bool) {
+	final, params, n := scanSequence(buf, 2)
+	if n == 0 {
+		return KeyEvent{}, 0, false
+	}
+
+	event, known = ss3Keys[final]
+	if len(params) > 0 {
+		// some terminals send modified keys as ESC O m x
+		event.Modifiers |= parseModifierParam(params[len(params)-1])
+	}
+	return event, n, known
+}
+
+// scanSequence returns the final byte, the parameters and the length of the sequence starting at offset, or 0 for incomplete sequences.
+func scanSequence(buf []byte, offset int) (final byte, params []int, n int) {
+	i := offset
+	for i < len(buf) && buf[i] >= 0x30 && buf[i] <= 0x3F {
+		i++
+	}
+	if i >= len(buf) {
+		return 0, nil, 0
+	}
+	return buf[i], parseCSIParams(string(buf[offset:i])), i + 1
+}
+
+// parseModifierParam converts the xterm modifier parameter to modifiers.
+func parseModifierParam(param int) Modifiers {
+	if param < 1 {
+		return 0
+	}
+	return Modifiers(param-1) & (ModShift | ModAlt | ModCtrl | ModMeta)
+}
+
+func parseCSIParams(str string) []int {
+	if len(str) == 0 {
+		return nil
+	}
+
+	parts := strings.Split(str, ";")
+	params := make([]int, len(parts))
+	for i := range parts {
+		// invalid or empty parameters are treated as 0
+		params[i], _ = strconv.Atoi(parts[i])
+	}
+	return params
+}
@@ -0,0 +1,209 @@
+package console
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeKeySequences(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		key   Key
+		r     rune
+	}{
+		{"Up", "\x1b[A", KeyUp, 0},
+		{"Down", "\x1b[B", KeyDown, 0},
+		{"Right", "\x1b[C", KeyRight, 0},
+		{"Left", "\x1b[D", KeyLeft, 0},
+		{"HomeXterm", "\x1b[H", KeyHome, 0},
+		{"EndXterm", "\x1b[F", KeyEnd, 0},
+		{"HomeVT", "\x1b[1~", KeyHome, 0},
+		{"EndVT", "\x1b[4~", KeyEnd, 0},
+		{"HomeRxvt", "\x1b[7~", KeyHome, 0},
+		{"EndRxvt", "\x1b[8~", KeyEnd, 0},
+		{"Insert", "\x1b[2~", KeyInsert, 0},
+		{"Delete", "\x1b[3~", KeyDelete, 0},
+		{"PageUp", "\x1b[5~", KeyPageUp, 0},
+		{"PageDown", "\x1b[6~", KeyPageDown, 0},
+		{"UpSS3", "\x1bOA", KeyUp, 0},
+		{"HomeSS3", "\x1bOH", KeyHome, 0},
+		{"EndSS3", "\x1bOF", KeyEnd, 0},
+		{"F1SS3", "\x1bOP", KeyF1, 0},
+		{"F4SS3", "\x1bOS", KeyF4, 0},
+		{"F1Rxvt", "\x1b[11~", KeyF1, 0},
+		{"F5", "\x1b[15~", KeyF5, 0},
+		{"F6", "\x1b[17~", KeyF6, 0},
+		{"F10", "\x1b[21~", KeyF10, 0},
+		{"F11", "\x1b[23~", KeyF11, 0},
+		{"F12", "\x1b[24~", KeyF12, 0},
+		{"F1Linux", "\x1b[[A", KeyF1, 0},
+		{"F5Linux", "\x1b[[E", KeyF5, 0},
+		{"Enter", "\r", KeyEnter, '\n'},
+		{"Backspace", "\x7f", KeyBackspace, '\r'},
+		{"BackspaceCtrlH", "\b", KeyBackspace, '\r'},
+		{"Tab", "\t", KeyTab, '\t'},
+		{"Space", " ", KeySpace, ' '},
+		{"Rune", "a", 0, 'a'},
+		{"RuneUTF8", "ö", 0, 'ö'},
+		{"Rune4Byte", "😀", 0, '😀'},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var d keyDecoder
+			d.Feed([]byte(test.input))
+			event, ok := d.Next(false)
+			assert.True(t, ok)
+			assert.Equal(t, KeyEvent{Key: test.key, Rune: test.r}, event)
+			assert.False(t, d.Pending())
+		})
+	}
+}
+
+func TestDecodeKeyStream(t *testing.T) {
+	var d keyDecoder
+	d.Feed([]byte("a\x1b[3~\x1bOBö\x1b[24~\r"))
+
+	expected := []struct {
+		key Key
+		r   rune
+	}{{0, 'a'}, {KeyDelete, 0}, {KeyDown, 0}, {0, 'ö'}, {KeyF12, 0}, {KeyEnter, '\n'}}
+	for _, e := range expected {
+		event, ok := d.Next(false)
+		assert.True(t, ok)
+		assert.Equal(t, KeyEvent{Key: e.key, Rune: e.r}, event)
+	}
+
+	_, ok := d.Next(false)
+	assert.False(t, ok)
+}
+
+func TestDecodeSplitSequence(t *testing.T) {
+	var d keyDecoder
+	for _, chunk := range []string{"\x1b", "[", "2", "1"} {
+		d.Feed([]byte(chunk))
+		_, ok := d.Next(false)
+		assert.False(t, ok)
+		assert.True(t, d.Pending())
+	}
+
+	d.Feed([]byte("~"))
+	event, ok := d.Next(false)
+	assert.True(t, ok)
+	assert.Equal(t, KeyEvent{Key: KeyF10}, event)
+	assert.False(t, d.Pending())
+}
+
+func TestDecodeSplitRune(t *testing.T) {
+	var d keyDecoder
+	raw := []byte("€")
+	d.Feed(raw[:1])
+	_, ok := d.Next(false)
+	assert.False(t, ok)
+	d.Feed(raw[1:2])
+	_, ok = d.Next(false)
+	assert.False(t, ok)
+	d.Feed(raw[2:])
+	event, ok := d.Next(false)
+	assert.True(t, ok)
+	assert.Equal(t, KeyEvent{Rune: '€'}, event)
+}
+
+func TestDecodeLoneEscape(t *testing.T) {
+	var d keyDecoder
+	d.Feed([]byte{27})
+	_, ok := d.Next(false)
+	assert.False(t, ok)
+
+	// no more input arrived in time
+	event, ok := d.Next(true)
+	assert.True(t, ok)
+	assert.Equal(t, KeyEscape, event.Key)
+	assert.False(t, d.Pending())
+}
+
+func TestDecodeDoubleEscape(t *testing.T) {
+	var d keyDecoder
+	d.Feed([]byte("\x1b\x1bx"))
+	event, ok := d.Next(false)
+	assert.True(t, ok)
+	assert.Equal(t, KeyEscape, event.Key)
+	event, ok = d.Next(false)
+	assert.True(t, ok)
+	assert.Equal(t, KeyEvent{Rune: 'x', Modifiers: ModAlt}, event)
+}
+
+func TestDecodeFlushIncompleteSequence(t *testing.T) {
+	var d keyDecoder
+	d.Feed([]byte("\x1b["))
+	event, ok := d.Next(true)
+	assert.True(t, ok)
+	assert.Equal(t, KeyEscape, event.Key)
+	event, ok = d.Next(true)
+	assert.True(t, ok)
+	assert.Equal(t, KeyEvent{Rune: '['}, event)
+}
+
+func TestDecodeUnknownSequence(t *testing.T) {
+	var d keyDecoder
+	d.Feed([]byte("\x1b[99~\x1b[Xa"))
+	event, ok := d.Next(false)
+	assert.True(t, ok)
+	assert.Equal(t, KeyEvent{Rune: 'a'}, event)
+	assert.False(t, d.Pending())
+}
+
+func TestDecodeModifiers(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected KeyEvent
+	}{
+		{"CtrlLeft", "\x1b[1;5D", KeyEvent{Key: KeyLeft, Modifiers: ModCtrl}},
+		{"ShiftRight", "\x1b[1;2C", KeyEvent{Key: KeyRight, Modifiers: ModShift}},
+		{"AltUp", "\x1b[1;3A", KeyEvent{Key: KeyUp, Modifiers: ModAlt}},
+		{"CtrlShiftHome", "\x1b[1;6H", KeyEvent{Key: KeyHome, Modifiers: ModCtrl | ModShift}},
+		{"CtrlDelete", "\x1b[3;5~", KeyEvent{Key: KeyDelete, Modifiers: ModCtrl}},
+		{"ShiftF5", "\x1b[15;2~", KeyEvent{Key: KeyF5, Modifiers: ModShift}},
+		{"ShiftF1", "\x1b[1;2P", KeyEvent{Key: KeyF1, Modifiers: ModShift}},
+		{"ShiftTab", "\x1b[Z", KeyEvent{Key: KeyTab, Rune: '\t', Modifiers: ModShift}},
+		{"RxvtShiftUp", "\x1b[a", KeyEvent{Key: KeyUp, Modifiers: ModShift}},
+		{"RxvtCtrlLeft", "\x1bOd", KeyEvent{Key: KeyLeft, Modifiers: ModCtrl}},
+		{"RxvtCtrlDelete", "\x1b[3^", KeyEvent{Key: KeyDelete, Modifiers: ModCtrl}},
+		{"RxvtShiftInsert", "\x1b[2$", KeyEvent{Key: KeyInsert, Modifiers: ModShift}},
+		{"SS3CtrlUp", "\x1bO5A", KeyEvent{Key: KeyUp, Modifiers: ModCtrl}},
+		{"AltB", "\x1bb", KeyEvent{Rune: 'b', Modifiers: ModAlt}},
+		{"AltUTF8", "\x1bö", KeyEvent{Rune: 'ö', Modifiers: ModAlt}},
+		{"AltBackspace", "\x1b\x7f", KeyEvent{Key: KeyBackspace, Rune: '\r', Modifiers: ModAlt}},
+		{"AltCtrlR", "\x1b\x12", KeyEvent{Key: KeyCtrlR, Modifiers: ModAlt | ModCtrl}},
+		{"AltEscPrefixedLeft", "\x1b\x1b[D", KeyEvent{Key: KeyLeft, Modifiers: ModAlt}},
+		{"CtrlC", "\x03", KeyEvent{Key: KeyCtrlC, Modifiers: ModCtrl}},
+		{"CtrlA", "\x01", KeyEvent{Key: KeyCtrlA, Modifiers: ModCtrl}},
+		{"CtrlW", "\x17", KeyEvent{Key: KeyCtrlW, Modifiers: ModCtrl}},
+		{"CtrlSpace", "\x00", KeyEvent{Key: KeySpace, Rune: ' ', Modifiers: ModCtrl}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var d keyDecoder
+			d.Feed([]byte(test.input))
+			event, ok := d.Next(false)
+			assert.True(t, ok)
+			assert.Equal(t, test.expected, event)
+			assert.False(t, d.Pending())
+		})
+	}
+}
+
+func TestDecodeSplitAltKey(t *testing.T) {
+	var d keyDecoder
+	d.Feed([]byte{27})
+	_, ok := d.Next(false)
+	assert.False(t, ok)
+	d.Feed([]byte("f"))
+	event, ok := d.Next(false)
+	assert.True(t, ok)
+	assert.Equal(t, KeyEvent{Rune: 'f', Modifiers: ModAlt}, event)
+}
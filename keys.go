@@ -2,6 +2,7 @@ package console
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/eiannone/keyboard"
 )
@@ -12,12 +13,52 @@ type Key keyboard.Key
 const (
 	// KeyEscape represents the escape key
 	KeyEscape = Key(keyboard.KeyEsc)
+	// KeyCtrlA represents the key combination Ctrl+A
+	KeyCtrlA = Key(keyboard.KeyCtrlA)
+	// KeyCtrlB represents the key combination Ctrl+B
+	KeyCtrlB = Key(keyboard.KeyCtrlB)
 	// KeyCtrlC represents the key combination Ctrl+C
 	KeyCtrlC = Key(keyboard.KeyCtrlC)
-	// KeyCtrlW represents the key combination Ctrl+W
-	KeyCtrlW = Key(keyboard.KeyCtrlW)
+	// KeyCtrlD represents the key combination Ctrl+D
+	KeyCtrlD = Key(keyboard.KeyCtrlD)
+	// KeyCtrlE represents the key combination Ctrl+E
+	KeyCtrlE = Key(keyboard.KeyCtrlE)
+	// KeyCtrlF represents the key combination Ctrl+F
+	KeyCtrlF = Key(keyboard.KeyCtrlF)
+	// KeyCtrlG represents the key combination Ctrl+G
+	KeyCtrlG = Key(keyboard.KeyCtrlG)
+	// KeyCtrlJ represents the key combination Ctrl+J
+	KeyCtrlJ = Key(keyboard.KeyCtrlJ)
+	// KeyCtrlK represents the key combination Ctrl+K
+	KeyCtrlK = Key(keyboard.KeyCtrlK)
+	// KeyCtrlL represents the key combination Ctrl+L
+	KeyCtrlL = Key(keyboard.KeyCtrlL)
+	// KeyCtrlN represents the key combination Ctrl+N
+	KeyCtrlN = Key(keyboard.KeyCtrlN)
+	// KeyCtrlO represents the key combination Ctrl+O
+	KeyCtrlO = Key(keyboard.KeyCtrlO)
+	// KeyCtrlP represents the key combination Ctrl+P
+	KeyCtrlP = Key(keyboard.KeyCtrlP)
+	// KeyCtrlQ represents the key combination Ctrl+Q
+	KeyCtrlQ = Key(keyboard.KeyCtrlQ)
+	// KeyCtrlR represents the key combination Ctrl+R
+	KeyCtrlR = Key(keyboard.KeyCtrlR)
 	// KeyCtrlS represents the key combination Ctrl+S
 	KeyCtrlS = Key(keyboard.KeyCtrlS)
+	// KeyCtrlT represents the key combination Ctrl+T
+	KeyCtrlT = Key(keyboard.KeyCtrlT)
+	// KeyCtrlU represents the key combination Ctrl+U
+	KeyCtrlU = Key(keyboard.KeyCtrlU)
+	// KeyCtrlV represents the key combination Ctrl+V
+	KeyCtrlV = Key(keyboard.KeyCtrlV)
+	// KeyCtrlW represents the key combination Ctrl+W
+	KeyCtrlW = Key(keyboard.KeyCtrlW)
+	// KeyCtrlX represents the key combination Ctrl+X
+	KeyCtrlX = Key(keyboard.KeyCtrlX)
+	// KeyCtrlY represents the key combination Ctrl+Y
+	KeyCtrlY = Key(keyboard.KeyCtrlY)
+	// KeyCtrlZ represents the key combination Ctrl+Z
+	KeyCtrlZ = Key(keyboard.KeyCtrlZ)
 	// KeyUp represents the arrow up key
 	KeyUp = Key(keyboard.KeyArrowUp)
 	// KeyDown represents the arrow down key
@@ -36,6 +77,8 @@ const (
 	KeyPageDown = Key(keyboard.KeyPgdn)
 	// KeyBackspace represents the backspace key
 	KeyBackspace = Key(keyboard.KeyBackspace2)
+	// KeyInsert represents the insert key
+	KeyInsert = Key(keyboard.KeyInsert)
 	// KeyDelete represents the delete key
 	KeyDelete = Key(keyboard.KeyDelete)
 	// KeyEnter represents the enter key
@@ -44,18 +87,38 @@ const (
 	KeyTab = Key(keyboard.KeyTab)
 	// KeySpace represents the space key
 	KeySpace = Key(keyboard.KeySpace)
+	// KeyF1 represents the function key F1
+	KeyF1 = Key(keyboard.KeyF1)
+	// KeyF2 represents the function key F2
+	KeyF2 = Key(keyboard.KeyF2)
+	// KeyF3 represents the function key F3
+	KeyF3 = Key(keyboard.KeyF3)
+	// KeyF4 represents the function key F4
+	KeyF4 = Key(keyboard.KeyF4)
+	// KeyF5 represents the function key F5
+	KeyF5 = Key(keyboard.KeyF5)
+	// KeyF6 represents the function key F6
+	KeyF6 = Key(keyboard.KeyF6)
+	// KeyF7 represents the function key F7
+	KeyF7 = Key(keyboard.KeyF7)
+	// KeyF8 represents the function key F8
+	KeyF8 = Key(keyboard.KeyF8)
+	// KeyF9 represents the function key F9
+	KeyF9 = Key(keyboard.KeyF9)
+	// KeyF10 represents the function key F10
+	KeyF10 = Key(keyboard.KeyF10)
+	// KeyF11 represents the function key F11
+	KeyF11 = Key(keyboard.KeyF11)
+	// KeyF12 represents the function key F12
+	KeyF12 = Key(keyboard.KeyF12)
 )
 
 func (k Key) String() string {
 	switch k {
 	case KeyEscape:
 		return "Escape"
-	case KeyCtrlC:
-		return "CtrlC"
-	case KeyCtrlW:
-		return "Ctrlw"
-	case KeyCtrlS:
-		return "CtrlS"
+	case KeyCtrlA, KeyCtrlB, KeyCtrlC, KeyCtrlD, KeyCtrlE, KeyCtrlF, KeyCtrlG, KeyCtrlJ, KeyCtrlK, KeyCtrlL, KeyCtrlN, KeyCtrlO, KeyCtrlP, KeyCtrlQ, KeyCtrlR, KeyCtrlS, KeyCtrlT, KeyCtrlU, KeyCtrlV, KeyCtrlW, KeyCtrlX, KeyCtrlY, KeyCtrlZ:
+		return fmt.Sprintf("Ctrl%c", 'A'+rune(k-KeyCtrlA))
 	case KeyUp:
 		return "ArrowUp"
 	case KeyDown:
@@ -74,6 +137,8 @@ func (k Key) String() string {
 		return "PageDown"
 	case KeyBackspace:
 		return "Backspace"
+	case KeyInsert:
+		return "Insert"
 	case KeyDelete:
 		return "Delete"
 	case KeyEnter:
@@ -82,8 +147,111 @@ func (k Key) String() string {
 		return "Tab"
 	case KeySpace:
 		return "Space"
+	case KeyF1:
+		return "F1"
+	case KeyF2:
+		return "F2"
+	case KeyF3:
+		return "F3"
+	case KeyF4:
+		return "F4"
+	case KeyF5:
+		return "F5"
+	case KeyF6:
+		return "F6"
+	case KeyF7:
+		return "F7"
+	case KeyF8:
+		return "F8"
+	case KeyF9:
+		return "F9"
+	case KeyF10:
+		return "F10"
+	case KeyF11:
+		return "F11"
+	case KeyF12:
+		return "F12"
 
 	default:
 		return fmt.Sprintf("Key[%d]", k)
 	}
 }
+
+// Modifiers is a bitmask of modifier keys held down while pressing a key.
+type Modifiers uint8
+
+const (
+	// ModShift denotes the shift key
+	ModShift Modifiers = 1 << iota
+	// ModAlt denotes the alt key
+	ModAlt
+	// ModCtrl denotes the control key
+	ModCtrl
+	// ModMeta denotes the meta key
+	ModMeta
+)
+
+// Has returns true when all modifiers in m are set.
+func (m Modifiers) Has(mod Modifiers) bool {
+	return m&mod == mod
+}
+
+func (m Modifiers) String() string {
+	var sb strings.Builder
+	for _, mod := range []struct {
+		mod  Modifiers
+		name string
+	}{{ModCtrl, "Ctrl"}, {ModAlt, "Alt"}, {ModShift, "Shift"}, {ModMeta, "Meta"}} {
+		if m.Has(mod.mod) {
+			if sb.Len() > 0 {
+				sb.WriteString("+")
+			}
+			sb.WriteString(mod.name)
+		}
+	}
+	return sb.String()
+}
+
+// KeyEvent represents a key press including the held modifier keys.
+type KeyEvent struct {
+	// Key denotes a special key or 0 for regular input.
+	Key Key
+	// Rune contains the entered character for regular input.
+	Rune rune
+	// Modifiers denotes the modifier keys held down.
+	Modifiers Modifiers
+}
+
+// Is returns true when the event denotes the given special key with exactly the given modifiers.
+func (e KeyEvent) Is(key Key, mods Modifiers) bool {
+	return e.Key == key && e.Modifiers == mods
+}
+
+// IsRune returns true when the event denotes the given character with exactly the given modifiers.
+func (e KeyEvent) IsRune(r rune, mods Modifiers) bool {
+	return e.Key == 0 && e.Rune == r && e.Modifiers == mods
+}
+
+func (e KeyEvent) String() string {
+	var name string
+	if e.Key == 0 {
+		name = fmt.Sprintf("%q", e.Rune)
+	} else {
+		name = e.Key.String()
+	}
+
+	// ctrl is already part of the key name for control characters
+	mods := e.Modifiers
+	if isCtrlKey(e.Key) {
+		mods &^= ModCtrl
+	}
+	if mods != 0 {
+		return mods.String() + "+" + name
+	}
+	return name
+}
+
+// isCtrlKey returns true for keys that represent a letter with held control key.
+func isCtrlKey(key Key) bool {
+	return key >= KeyCtrlA && key <= KeyCtrlZ && key != KeyTab && key != KeyEnter && key != Key(keyboard.KeyCtrlH)
+}
@@ -0,0 +1,159 @@
+package console
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// streamInput reads raw terminal input from an arbitrary reader, e.g. an SSH channel, in a background routine.
+type streamInput struct {
+	lineReader
+	r io.Reader
+
+	start  sync.Once
+	chunks chan streamChunk
+	err    error
+
+	// buf holds received data that has not been consumed by ReadLine yet.
+	buf     []byte
+	decoder keyDecoder
+}
+
+type streamChunk struct {
+	data []byte
+	err  error
+}
+
+func newStreamInput(r io.Reader) *streamInput {
+	return &streamInput{r: r, chunks: make(chan streamChunk)}
+}
+
+func (s *streamInput) pump() {
+	for {
+		buf := make([]byte, 256)
+		n, err := s.r.Read(buf)
+		if n > 0 {
+			s.chunks <- streamChunk{data: buf[:n]}
+		}
+		if err != nil {
+			s.chunks <- streamChunk{err: err}
+			return
+		}
+	}
+}
+
+// receive returns the next chunk of data. ok is false when timeout fires before, a nil timeout channel waits forever.
+func (s *streamInput) receive(ctx context.Context, timeout <-chan time.Time) (data []byte, ok bool, err error) {
+	if s.err != nil {
+		return nil, false, s.err
+	}
+	s.start.Do(func() { go s.pump() })
+
+	select {
+	case chunk := <-s.chunks:
+		if chunk.err != nil {
+			// the reader must not be used after an error
+			s.err = chunk.err
+			return nil, false, chunk.err
+		}
+		return chunk.data, true, nil
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	case <-timeout:
+		return nil, false, nil
+	}
+}
+
+func (s *streamInput) readRune(ctx context.Context) (rune, error) {
+	return readRuneUTF8(&streamReader{s, ctx})
+}
+
+func (s *streamInput) ReadLine() (string, error) {
+	return s.ReadLineContext(context.Background())
+}
+
+func (s *streamInput) ReadLineContext(ctx context.Context) (string, error) {
+	return s.readLine(func() (rune, error) { return s.readRune(ctx) })
+}
+
+func (s *streamInput) ReadPassword() (string, error) {
+	// the remote side of a stream does not echo input on its own
+	return s.ReadLine()
+}
+
+func (s *streamInput) BeginReadKey() error {
+	s.decoder.Reset()
+	return nil
+}
+
+func (s *streamInput) ReadKey() (Key, rune, error) {
+	event, err := s.ReadKeyEventContext(context.Background())
+	return event.Key, event.Rune, err
+}
+
+func (s *streamInput) ReadKeyEvent() (KeyEvent, error) {
+	return s.ReadKeyEventContext(context.Background())
+}
+
+func (s *streamInput) ReadKeyEventContext(ctx context.Context) (KeyEvent, error) {
+	if len(s.buf) > 0 {
+		// take over data received during ReadLine
+		s.decoder.Feed(s.buf)
+		s.buf = nil
+	}
+
+	for {
+		if event, ok := s.decoder.Next(false); ok {
+			return event, nil
+		}
+
+		var timeout <-chan time.Time
+		if s.decoder.Pending() {
+			// incomplete sequence in buffer: wait some time for the remainder before taking it as it is
+			timeout = time.After(escapeSequenceTimeout)
+		}
+		data, ok, err := s.receive(ctx, timeout)
+		if err != nil {
+			if s.err != nil {
+				// no more input will arrive: take remaining data as it is
+				if event, ok := s.decoder.Next(true); ok {
+					return event, nil
+				}
+			}
+			return KeyEvent{}, err
+		}
+		if !ok {
+			if event, ok := s.decoder.Next(true); ok {
+				return event, nil
+			}
+			continue
+		}
+		s.decoder.Feed(data)
+	}
+}
+
+func (s *streamInput) EndReadKey() error {
+	s.decoder.Reset()
+	return nil
+}
+
+// streamReader reads buffered data of a streamInput until the context is done.
+type streamReader struct {
+	s   *streamInput
+	ctx context.Context
+}
+
+func (r *streamReader) Read(buf []byte) (int, error) {
+	if len(r.s.buf) == 0 {
+		data, _, err := r.s.receive(r.ctx, nil)
+		if err != nil {
+			return 0, err
+		}
+		r.s.buf = data
+	}
+	n := copy(buf, r.s.buf)
+	r.s.buf = r.s.buf[n:]
+	return n, nil
+}
@@ -0,0 +1,338 @@
+package console
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ColorDepth denotes the number of colors a terminal can display.
+type ColorDepth int
+
+const (
+	// ColorDepthNone denotes a terminal without color support.
+	ColorDepthNone ColorDepth = iota
+	// ColorDepth16 denotes the 8 basic colors and their bright variants.
+	ColorDepth16
+	// ColorDepth256 denotes the xterm 256 color palette.
+	ColorDepth256
+	// ColorDepthTrueColor denotes 24 bit RGB colors.
+	ColorDepthTrueColor
+)
+
+func (d ColorDepth) String() string {
+	switch d {
+	case ColorDepthNone:
+		return "none"
+	case ColorDepth16:
+		return "16"
+	case ColorDepth256:
+		return "256"
+	case ColorDepthTrueColor:
+		return "truecolor"
+	default:
+		return fmt.Sprintf("ColorDepth(%d)", int(d))
+	}
+}
+
+// Color denotes a terminal color. The zero value is the default color of the terminal.
+type Color uint32
+
+const (
+	colorKindMask   Color = 0xFF000000
+	colorKind16     Color = 1 << 24
+	colorKind256    Color = 2 << 24
+	colorKindRGB    Color = 3 << 24
+	colorIndexMask  Color = 0x000000FF
+	colorRGBMask    Color = 0x00FFFFFF
+	colorCubeOffset       = 16
+	colorGrayOffset       = 232
+)
+
+// Basic colors that are available on all terminals with color support.
+const (
+	ColorDefault       Color = 0
+	ColorBlack         Color = colorKind16 | 0
+	ColorRed           Color = colorKind16 | 1
+	ColorGreen         Color = colorKind16 | 2
+	ColorYellow        Color = colorKind16 | 3
+	ColorBlue          Color = colorKind16 | 4
+	ColorMagenta       Color = colorKind16 | 5
+	ColorCyan          Color = colorKind16 | 6
+	ColorWhite         Color = colorKind16 | 7
+	ColorBrightBlack   Color = colorKind16 | 8
+	ColorBrightRed     Color = colorKind16 | 9
+	ColorBrightGreen   Color = colorKind16 | 10
+	ColorBrightYellow  Color = colorKind16 | 11
+	ColorBrightBlue    Color = colorKind16 | 12
+	ColorBrightMagenta Color = colorKind16 | 13
+	ColorBrightCyan    Color = colorKind16 | 14
+	ColorBrightWhite   Color = colorKind16 | 15
+)
+
+// palette16 contains the typical RGB values of the basic colors used for downgrading.
+var palette16 = [16][3]uint8{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0}, {0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0}, {92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// cubeLevels contains the channel values of the 6x6x6 color cube of the 256 color palette.
+var cubeLevels = [6]uint8{0, 95, 135, 175, 215, 255}
+
+// Color256 returns a color of the xterm 256 color palette.
+func Color256(index uint8) Color {
+	return colorKind256 | Color(index)
+}
+
+// ColorRGB returns a 24 bit color.
+func ColorRGB(r, g, b uint8) Color {
+	return colorKindRGB | Color(r)<<16 | Color(g)<<8 | Color(b)
+}
+
+// RGB returns the red, green and blue components of the color. The default color is reported as black.
+func (c Color) RGB() (uint8, uint8, uint8) {
+	switch c & colorKindMask {
+	case colorKind16:
+		p := palette16[c&colorIndexMask&0x0F]
+		return p[0], p[1], p[2]
+
+	case colorKind256:
+		index := int(c & colorIndexMask)
+		if index < colorCubeOffset {
+			p := palette16[index]
+			return p[0], p[1], p[2]
+		}
+		if index < colorGrayOffset {
+			index -= colorCubeOffset
+			return cubeLevels[index/36], cubeLevels[(index/6)%6], cubeLevels[index%6]
+		}
+		gray := uint8(8 + 10*(index-colorGrayOffset))
+		return gray, gray, gray
+
+	case colorKindRGB:
+		return uint8(c >> 16), uint8(c >> 8), uint8(c)
+	}
+	return 0, 0, 0
+}
+
+// downgrade converts the color to the nearest color available for the given depth.
+func (c Color) downgrade(depth ColorDepth) Color {
+	if c == ColorDefault || depth == ColorDepthNone {
+		return ColorDefault
+	}
+
+	switch c & colorKindMask {
+	case colorKind256:
+		if depth < ColorDepth256 {
+			if index := c & colorIndexMask; index < colorCubeOffset {
+				return colorKind16 | index
+			}
+			return nearestColor16(c.RGB())
+		}
+
+	case colorKindRGB:
+		if depth < ColorDepth256 {
+			return nearestColor16(c.RGB())
+		}
+		if depth < ColorDepthTrueColor {
+			return nearestColor256(c.RGB())
+		}
+	}
+	return c
+}
+
+func nearestColor16(r, g, b uint8) Color {
+	best := 0
+	bestDist := -1
+	for i, p := range palette16 {
+		if dist := colorDistance(r, g, b, p[0], p[1], p[2]); bestDist < 0 || dist < bestDist {
+			best, bestDist = i, dist
+		}
+	}
+	return colorKind16 | Color(best)
+}
+
+func nearestColor256(r, g, b uint8) Color {
+	nearestLevel := func(v uint8) int {
+		best := 0
+		for i := range cubeLevels {
+			if absDiff(v, cubeLevels[i]) < absDiff(v, cubeLevels[best]) {
+				best = i
+			}
+		}
+		return best
+	}
+
+	// candidate from color cube
+	ri, gi, bi := nearestLevel(r), nearestLevel(g), nearestLevel(b)
+	cube := Color256(uint8(colorCubeOffset + 36*ri + 6*gi + bi))
+
+	// candidate from grayscale ramp
+	avg := (int(r) + int(g) + int(b)) / 3
+	grayIndex := (avg - 3) / 10
+	if grayIndex < 0 {
+		grayIndex = 0
+	} else if grayIndex > 23 {
+		grayIndex = 23
+	}
+	gray := Color256(uint8(colorGrayOffset + grayIndex))
+
+	cr, cg, cb := cube.RGB()
+	gr, gg, gb := gray.RGB()
+	if colorDistance(r, g, b, gr, gg, gb) < colorDistance(r, g, b, cr, cg, cb) {
+		return gray
+	}
+	return cube
+}
+
+func colorDistance(r1, g1, b1, r2, g2, b2 uint8) int {
+	dr := int(r1) - int(r2)
+	dg := int(g1) - int(g2)
+	db := int(b1) - int(b2)
+	return dr*dr + dg*dg + db*db
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// sgrParams returns the SGR parameters to select the color as foreground or background color.
+func (c Color) sgrParams(background bool) []string {
+	switch c & colorKindMask {
+	case colorKind16:
+		index := int(c & colorIndexMask)
+		base := 30
+		if background {
+			base = 40
+		}
+		if index >= 8 {
+			// bright colors
+			base += 60
+			index -= 8
+		}
+		return []string{strconv.Itoa(base + index)}
+
+	case colorKind256:
+		if background {
+			return []string{"48", "5", strconv.Itoa(int(c & colorIndexMask))}
+		}
+		return []string{"38", "5", strconv.Itoa(int(c & colorIndexMask))}
+
+	case colorKindRGB:
+		r, g, b := c.RGB()
+		prefix := "38"
+		if background {
+			prefix = "48"
+		}
+		return []string{prefix, "2", strconv.Itoa(int(r)), strconv.Itoa(int(g)), strconv.Itoa(int(b))}
+	}
+	return nil
+}
+
+// Style describes the appearance of printed text.
+type Style struct {
+	Foreground Color
+	Background Color
+	Bold       bool
+	Italic     bool
+	Underline  bool
+}
+
+// escapeSequence returns the ANSI escape sequence to enable the style for the given color depth.
+func (s Style) escapeSequence(depth ColorDepth) string {
+	if depth == ColorDepthNone {
+		return ""
+	}
+
+	params := make([]string, 0)
+	if s.Bold {
+		params = append(params, "1")
+	}
+	if s.Italic {
+		params = append(params, "3")
+	}
+	if s.Underline {
+		params = append(params, "4")
+	}
+	params = append(params, s.Foreground.downgrade(depth).sgrParams(false)...)
+	params = append(params, s.Background.downgrade(depth).sgrParams(true)...)
+
+	if len(params) == 0 {
+		return ""
+	}
+	return "\x1b[" + strings.Join(params, ";") + "m"
+}
+
+// render surrounds str with the escape sequences of the style.
+func (s Style) render(depth ColorDepth, str string) string {
+	seq := s.escapeSequence(depth)
+	if len(seq) == 0 {
+		return str
+	}
+	return seq + str + "\x1b[0m"
+}
+
+// Sprint formats a like fmt.Sprint and applies the style for the given color depth.
+func (s Style) Sprint(depth ColorDepth, a ...interface{}) string {
+	return s.render(depth, fmt.Sprint(a...))
+}
+
+// colorDepthOutput is implemented by outputs that know the number of supported colors.
+type colorDepthOutput interface {
+	ColorDepth() ColorDepth
+}
+
+// ColorDepth returns the number of colors to use for styled output. FORCE_COLOR and NO_COLOR are respected.
+func (c *Console) ColorDepth() ColorDepth {
+	if level, forced := forcedColorLevel(os.LookupEnv); forced {
+		if level == ColorDepthNone {
+			return ColorDepthNone
+		}
+	} else if len(os.Getenv("NO_COLOR")) > 0 {
+		return ColorDepthNone
+	}
+
+	output := c.Output()
+	if !output.SupportsColors() {
+		return ColorDepthNone
+	}
+	if o, ok := output.(colorDepthOutput); ok {
+		return o.ColorDepth()
+	}
+	return ColorDepth16
+}
+
+// SprintStyled formats a set of objects like fmt.Sprint and applies the style if supported by the console.
+func (c *Console) SprintStyled(style Style, a ...interface{}) string {
+	return style.Sprint(c.ColorDepth(), a...)
+}
+
+// PrintStyled writes a set of objects separated by whitespaces to the console using the given style.
+func (c *Console) PrintStyled(style Style, a ...interface{}) (int, error) {
+	return c.Output().Print(c.SprintStyled(style, a...))
+}
+
+// PrintlnStyled writes a set of objects separated by whitespaces to the console using the given style and ends the line.
+func (c *Console) PrintlnStyled(style Style, a ...interface{}) (int, error) {
+	// line break is not styled to prevent background colors from leaking into the next line
+	return c.Output().Print(c.SprintStyled(style, strings.TrimSuffix(fmt.Sprintln(a...), "\n")) + newline)
+}
+
+// SprintStyled formats a set of objects like fmt.Sprint and applies the style if supported by Stdout.
+func SprintStyled(style Style, a ...interface{}) string {
+	return defaultConsole.SprintStyled(style, a...)
+}
+
+// PrintStyled writes a set of objects separated by whitespaces to Stdout using the given style.
+func PrintStyled(style Style, a ...interface{}) (int, error) {
+	return defaultConsole.PrintStyled(style, a...)
+}
+
+// PrintlnStyled writes a set of objects separated by whitespaces to Stdout using the given style and ends the line.
+func PrintlnStyled(style Style, a ...interface{}) (int, error) {
+	return defaultConsole.PrintlnStyled(style, a...)
+}
@@ -0,0 +1,114 @@
+package console
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStyleEscapeSequence(t *testing.T) {
+	tests := []struct {
+		name     string
+		style    Style
+		depth    ColorDepth
+		expected string
+	}{
+		{"Empty", Style{}, ColorDepthTrueColor, ""},
+		{"Bold", Style{Bold: true}, ColorDepth16, "\x1b[1m"},
+		{"AllAttributes", Style{Bold: true, Italic: true, Underline: true}, ColorDepth16, "\x1b[1;3;4m"},
+		{"Foreground", Style{Foreground: ColorRed}, ColorDepth16, "\x1b[31m"},
+		{"BrightBackground", Style{Background: ColorBrightCyan}, ColorDepth16, "\x1b[106m"},
+		{"BoldBlue", Style{Foreground: ColorBlue, Bold: true}, ColorDepth16, "\x1b[1;34m"},
+		{"Color256", Style{Foreground: Color256(208), Background: Color256(22)}, ColorDepth256, "\x1b[38;5;208;48;5;22m"},
+		{"TrueColor", Style{Foreground: ColorRGB(255, 128, 0)}, ColorDepthTrueColor, "\x1b[38;2;255;128;0m"},
+		{"TrueColorBackground", Style{Background: ColorRGB(1, 2, 3)}, ColorDepthTrueColor, "\x1b[48;2;1;2;3m"},
+		{"NoColors", Style{Foreground: ColorRed, Bold: true}, ColorDepthNone, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.style.escapeSequence(test.depth))
+		})
+	}
+}
+
+func TestColorDowngrade(t *testing.T) {
+	// true color to 256 colors
+	assert.Equal(t, Color256(208), ColorRGB(255, 135, 0).downgrade(ColorDepth256))
+	assert.Equal(t, Color256(244), ColorRGB(128, 128, 128).downgrade(ColorDepth256))
+	assert.Equal(t, Color256(16), ColorRGB(0, 0, 0).downgrade(ColorDepth256))
+
+	// true color to 16 colors
+	assert.Equal(t, ColorBrightRed, ColorRGB(250, 10, 10).downgrade(ColorDepth16))
+	assert.Equal(t, ColorBlue, ColorRGB(0, 0, 200).downgrade(ColorDepth16))
+	assert.Equal(t, ColorBrightWhite, ColorRGB(255, 255, 255).downgrade(ColorDepth16))
+
+	// 256 colors to 16 colors
+	assert.Equal(t, ColorYellow, Color256(3).downgrade(ColorDepth16))
+	assert.Equal(t, ColorBrightGreen, Color256(46).downgrade(ColorDepth16))
+	assert.Equal(t, ColorBlack, Color256(233).downgrade(ColorDepth16))
+
+	// colors that are supported stay untouched
+	assert.Equal(t, ColorRed, ColorRed.downgrade(ColorDepth16))
+	assert.Equal(t, Color256(100), Color256(100).downgrade(ColorDepthTrueColor))
+	assert.Equal(t, ColorDefault, ColorDefault.downgrade(ColorDepth16))
+}
+
+func TestColorRGB(t *testing.T) {
+	r, g, b := ColorRGB(1, 2, 3).RGB()
+	assert.Equal(t, []uint8{1, 2, 3}, []uint8{r, g, b})
+	r, g, b = Color256(196).RGB()
+	assert.Equal(t, []uint8{255, 0, 0}, []uint8{r, g, b})
+	r, g, b = Color256(255).RGB()
+	assert.Equal(t, []uint8{238, 238, 238}, []uint8{r, g, b})
+}
+
+func TestSprintStyled(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	style := Style{Foreground: ColorRGB(255, 0, 0), Underline: true}
+
+	c := New(strings.NewReader(""), io.Discard, nil)
+	assert.Equal(t, ColorDepthNone, c.ColorDepth())
+	assert.Equal(t, "foo 42", c.SprintStyled(style, "foo ", 42))
+
+	c = New(strings.NewReader(""), io.Discard, &Options{SupportsColors: true})
+	assert.Equal(t, ColorDepth16, c.ColorDepth())
+	assert.Equal(t, "\x1b[4;91mfoo\x1b[0m", c.SprintStyled(style, "foo"))
+
+	c = New(strings.NewReader(""), io.Discard, &Options{ColorDepth: ColorDepthTrueColor})
+	assert.Equal(t, "\x1b[4;38;2;255;0;0mfoo\x1b[0m", c.SprintStyled(style, "foo"))
+}
+
+func TestSprintStyledNoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	c := New(strings.NewReader(""), io.Discard, &Options{ColorDepth: ColorDepthTrueColor})
+	assert.Equal(t, ColorDepthNone, c.ColorDepth())
+	assert.Equal(t, "foo", c.SprintStyled(Style{Foreground: ColorRed}, "foo"))
+}
+
+func TestSprintStyledForceColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	c := New(strings.NewReader(""), io.Discard, &Options{ColorDepth: ColorDepthTrueColor})
+
+	t.Setenv("FORCE_COLOR", "1")
+	assert.Equal(t, ColorDepthTrueColor, c.ColorDepth())
+	for _, val := range []string{"0", "false"} {
+		t.Setenv("FORCE_COLOR", val)
+		assert.Equal(t, ColorDepthNone, c.ColorDepth())
+	}
+	t.Setenv("NO_COLOR", "")
+	assert.Equal(t, ColorDepthNone, c.ColorDepth())
+}
+
+func TestPrintlnStyled(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	var sb strings.Builder
+	c := New(strings.NewReader(""), &sb, &Options{SupportsColors: true})
+
+	c.PrintStyled(Style{Bold: true}, "foo")
+	c.PrintlnStyled(Style{Background: ColorGreen}, "bar", 42)
+	assert.Equal(t, "\x1b[1mfoo\x1b[0m\x1b[42mbar 42\x1b[0m\n", sb.String())
+}
@@ -0,0 +1,192 @@
+package console
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// terminalInput reads from a terminal file. Stdin and the controlling terminal are used when file is nil.
+type terminalInput struct {
+	lineReader
+	file *os.File
+	tty  ttyState
+}
+
+func (d *terminalInput) in() *os.File {
+	if d.file == nil {
+		return os.Stdin
+	}
+	return d.file
+}
+
+func (d *terminalInput) ReadLine() (string, error) {
+	//TODO configurable encoding
+	return d.readLine(func() (rune, error) { return readRuneUTF8(d.in()) })
+}
+
+func (d *terminalInput) ReadLineContext(ctx context.Context) (string, error) {
+	r, err := newContextReader(ctx, d.in())
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	return d.readLine(func() (rune, error) { return readRuneUTF8(r) })
+}
+
+func (d *terminalInput) ReadPassword() (string, error) {
+	var pw string
+	if err := withoutEcho(d.in(), func() error {
+		line, err := d.ReadLine()
+		pw = line
+		return err
+	}); err != nil {
+		return "", err
+	}
+	return pw, nil
+}
+
+func (d *terminalInput) BeginReadKey() error {
+	return d.tty.begin(d.file)
+}
+
+func (d *terminalInput) ReadKey() (Key, rune, error) {
+	event, err := d.tty.readKeyEvent(context.Background())
+	return event.Key, event.Rune, err
+}
+
+func (d *terminalInput) ReadKeyEvent() (KeyEvent, error) {
+	return d.tty.readKeyEvent(context.Background())
+}
+
+func (d *terminalInput) ReadKeyEventContext(ctx context.Context) (KeyEvent, error) {
+	return d.tty.readKeyEvent(ctx)
+}
+
+func (d *terminalInput) EndReadKey() error {
+	return d.tty.end()
+}
+
+// lineReader assembles lines from single runes and handles '\r', '\n' and '\r\n' line breaks.
+type lineReader struct {
+	lastCharWasCR bool
+}
+
+func (d *lineReader) readLine(readRune func() (rune, error)) (string, error) {
+	var sb strings.Builder
+
+	for {
+		r, err := readRune()
+		if err != nil {
+			return sb.String(), err
+		}
+
+		if r == '\r' {
+			d.lastCharWasCR = true
+			return sb.String(), nil
+		} else if r == '\n' {
+			if d.lastCharWasCR {
+				// just ignore that char to be compatible with windows \r\n
+				d.lastCharWasCR = false
+			} else {
+				d.lastCharWasCR = false
+				return sb.String(), nil
+			}
+		} else {
+			d.lastCharWasCR = false
+			sb.WriteRune(r)
+		}
+	}
+}
+
+func readRuneANSI(r io.Reader) (rune, error) {
+	var buf = [1]byte{0}
+	_, err := r.Read(buf[:])
+	if err != nil {
+		return 0, err
+	}
+	return rune(buf[0]), nil
+}
+
+func readRuneUTF8(r io.Reader) (rune, error) {
+	// utf8 runes can take 1 up to 4 bytes
+	var buf = [4]byte{0}
+	_, err := r.Read(buf[0:1])
+	if err != nil {
+		return 0, err
+	}
+
+	// most common case: rune takes exactly one byte
+	if !utf8.FullRune(buf[0:1]) {
+		// not complete yet? read next byte and check again
+		for i := 1; i < 4; i++ {
+			// put next byte into buffer
+			_, err := r.Read(buf[i : i+1])
+			if err != nil {
+				return 0, err
+			}
+			if i < 3 {
+				// skip check for last rune -> will terminate either way
+				if utf8.FullRune(buf[0 : i+1]) {
+					break
+				}
+			}
+		}
+	}
+
+	decoded, _ := utf8.DecodeRune(buf[:])
+	return decoded, nil
+}
+
+// writerOutput writes to an arbitrary writer and takes terminal properties from the options unless it is a terminal file.
+type writerOutput struct {
+	w    io.Writer
+	opts Options
+}
+
+func (d *writerOutput) Print(str string) (int, error) {
+	return fmt.Fprint(d.w, str)
+}
+
+func (d *writerOutput) GetSize() (int, int, error) {
+	if d.opts.GetSize != nil {
+		return d.opts.GetSize()
+	}
+	if f, ok := d.w.(*os.File); ok {
+		return term.GetSize(int(f.Fd()))
+	}
+	return 0, 0, fmt.Errorf("terminal size unknown for output of type %T", d.w)
+}
+
+func (d *writerOutput) SupportsColors() bool {
+	return d.ColorDepth() > ColorDepthNone
+}
+
+func (d *writerOutput) ColorDepth() ColorDepth {
+	if f, ok := d.w.(*os.File); ok {
+		if level := colorLevel(f); level > ColorDepthNone {
+			return level
+		}
+	}
+	if d.opts.ColorDepth > ColorDepthNone {
+		return d.opts.ColorDepth
+	}
+	if d.opts.SupportsColors {
+		return ColorDepth16
+	}
+	return ColorDepthNone
+}
+
+func (d *writerOutput) Exit(code int) {
+	if d.opts.Exit != nil {
+		d.opts.Exit(code)
+		return
+	}
+	os.Exit(code)
+}
@@ -0,0 +1,107 @@
+//go:build !windows
+
+package console
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// ttyState holds the raw mode state of a terminal between BeginReadKey and EndReadKey.
+type ttyState struct {
+	in         *os.File
+	closeIn    bool
+	oldTermios syscall.Termios
+	decoder    keyDecoder
+}
+
+// begin switches f to raw mode. The controlling terminal is opened when f is nil.
+func (t *ttyState) begin(f *os.File) error {
+	closeIn := false
+	if f == nil {
+		in, err := os.OpenFile("/dev/tty", syscall.O_RDONLY, 0)
+		if err != nil {
+			return err
+		}
+		f = in
+		closeIn = true
+	}
+	closeOnError := func() {
+		if closeIn {
+			f.Close()
+		}
+	}
+
+	if _, _, err := syscall.Syscall(syscall.SYS_IOCTL, uintptr(f.Fd()), ioctlReadTermios, uintptr(unsafe.Pointer(&t.oldTermios))); err != 0 {
+		closeOnError()
+		return err
+	}
+	newTermios := t.oldTermios
+	newTermios.Iflag &^= syscall.ISTRIP | syscall.INLCR | syscall.ICRNL | syscall.IGNCR | syscall.IXOFF
+	newTermios.Lflag &^= syscall.ECHO | syscall.ICANON
+	//TODO catch Ctrl+C
+	if _, _, err := syscall.Syscall(syscall.SYS_IOCTL, uintptr(f.Fd()), ioctlWriteTermios, uintptr(unsafe.Pointer(&newTermios))); err != 0 {
+		closeOnError()
+		return err
+	}
+
+	t.in = f
+	t.closeIn = closeIn
+	t.decoder.Reset()
+	return nil
+}
+
+func (t *ttyState) readKeyEvent(ctx context.Context) (KeyEvent, error) {
+	interrupt, err := newInterruptPipe(ctx)
+	if err != nil {
+		return KeyEvent{}, err
+	}
+	defer interrupt.Close()
+
+	buf := make([]byte, 64)
+	for {
+		if event, ok := t.decoder.Next(false); ok {
+			return event, nil
+		}
+
+		timeout := time.Duration(-1)
+		if t.decoder.Pending() {
+			// incomplete sequence in buffer: wait some time for the remainder before taking it as it is
+			timeout = escapeSequenceTimeout
+		}
+		ready, err := interrupt.WaitForInput(t.in, timeout)
+		if err != nil {
+			return KeyEvent{}, err
+		}
+		if !ready {
+			if event, ok := t.decoder.Next(true); ok {
+				return event, nil
+			}
+			continue
+		}
+
+		n, err := t.in.Read(buf)
+		if err != nil {
+			return KeyEvent{}, err
+		}
+		t.decoder.Feed(buf[:n])
+	}
+}
+
+func (t *ttyState) end() error {
+	if t.in == nil {
+		// BeginReadKey has not been called or has failed
+		return nil
+	}
+	if _, _, err := syscall.Syscall(syscall.SYS_IOCTL, uintptr(t.in.Fd()), ioctlWriteTermios, uintptr(unsafe.Pointer(&t.oldTermios))); err != 0 {
+		return err
+	}
+	if t.closeIn {
+		t.in.Close()
+	}
+	t.in = nil
+	return nil
+}
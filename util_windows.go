@@ -3,14 +3,15 @@
 package console
 
 import (
+	"context"
 	"os"
+	"unsafe"
 
-	"github.com/eiannone/keyboard"
 	"golang.org/x/sys/windows"
 )
 
-func withoutEcho(f func() error) error {
-	fd := os.Stdin.Fd()
+func withoutEcho(in *os.File, f func() error) error {
+	fd := in.Fd()
 
 	var st uint32
 	if err := windows.GetConsoleMode(windows.Handle(fd), &st); err != nil {
@@ -43,29 +44,125 @@ func supportsColors() bool {
 	return false
 }
 
-func beginReadKey() error {
+// ttyState holds the raw mode state of the console between BeginReadKey and EndReadKey.
+type ttyState struct {
+	// pending receives the result of a read left behind by a cancelled call to take it over on the next call.
+	pending chan keyResult
+}
+
+type keyResult struct {
+	event KeyEvent
+	err   error
+}
+
+func (t *ttyState) begin(*os.File) error {
 	//return keyboard.Open()
 	return nil
 }
 
-func readKey() (Key, rune, error) {
-	// does not work when inserting text
-	//char, key, err := keyboard.GetKey()
-	char, key, err := keyboard.GetSingleKey()
-	if err != nil {
-		return 0, 0, err
+func (t *ttyState) readKeyEvent(ctx context.Context) (KeyEvent, error) {
+	if t.pending == nil {
+		if ctx.Done() == nil {
+			// context can never be cancelled
+			return readKeyEventBlocking()
+		}
+
+		ch := make(chan keyResult, 1)
+		go func() {
+			event, err := readKeyEventBlocking()
+			ch <- keyResult{event, err}
+		}()
+		t.pending = ch
 	}
 
-	// re-map keys
-	switch key {
-	case keyboard.KeyBackspace:
-		key = keyboard.KeyBackspace2
+	select {
+	case r := <-t.pending:
+		t.pending = nil
+		return r.event, r.err
+	case <-ctx.Done():
+		return KeyEvent{}, ctx.Err()
 	}
+}
+
+var (
+	kernel32              = windows.NewLazySystemDLL("kernel32.dll")
+	procReadConsoleInputW = kernel32.NewProc("ReadConsoleInputW")
+)
+
+// winInputRecord mirrors the INPUT_RECORD of the windows console. event holds a winKeyRecord for key events.
+type winInputRecord struct {
+	eventType uint16
+	_         uint16
+	event     winKeyRecord
+}
+
+// winKeyEventType denotes the event type of key events in an INPUT_RECORD.
+const winKeyEventType = 0x1
 
-	return Key(key), char, nil
+func readKeyEventBlocking() (KeyEvent, error) {
+	in, err := windows.Open("CONIN$", windows.O_RDWR, 0)
+	if err != nil {
+		return KeyEvent{}, err
+	}
+	defer windows.Close(in)
+
+	for {
+		var record winInputRecord
+		var n uint32
+		if r, _, err := procReadConsoleInputW.Call(uintptr(in), uintptr(unsafe.Pointer(&record)), 1, uintptr(unsafe.Pointer(&n))); r == 0 {
+			return KeyEvent{}, err
+		}
+		if n == 0 || record.eventType != winKeyEventType {
+			continue
+		}
+		// modifiers are taken from the control key state, which is not available through the keyboard package
+		if event, ok := record.event.keyEvent(); ok {
+			return event, nil
+		}
+	}
 }
 
-func endReadKey() error {
+func (t *ttyState) end() error {
 	//keyboard.Close()
 	return nil
 }
+
+// contextReader reads from a file until the context is done. Pending reads are left behind on cancellation.
+type contextReader struct {
+	ctx context.Context
+	f   *os.File
+}
+
+func newContextReader(ctx context.Context, f *os.File) (*contextReader, error) {
+	return &contextReader{ctx, f}, nil
+}
+
+func (r *contextReader) Read(buf []byte) (int, error) {
+	if r.ctx.Done() == nil {
+		return r.f.Read(buf)
+	}
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	type result struct {
+		data []byte
+		err  error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		tmp := make([]byte, len(buf))
+		n, err := r.f.Read(tmp)
+		ch <- result{tmp[:n], err}
+	}()
+
+	select {
+	case res := <-ch:
+		return copy(buf, res.data), res.err
+	case <-r.ctx.Done():
+		return 0, r.ctx.Err()
+	}
+}
+
+func (r *contextReader) Close() {
+}
@@ -0,0 +1,63 @@
+package console
+
+import (
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+// StringWidth returns the number of terminal columns needed to display str. Escape sequences are ignored.
+func StringWidth(str string) int {
+	return uniseg.StringWidth(StripEscapeSequences(str))
+}
+
+// StripEscapeSequences removes all ANSI escape sequences from str.
+func StripEscapeSequences(str string) string {
+	if !strings.ContainsRune(str, '\x1b') {
+		return str
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(str); i++ {
+		if str[i] != '\x1b' {
+			sb.WriteByte(str[i])
+			continue
+		}
+		if i+1 >= len(str) {
+			break
+		}
+
+		switch str[i+1] {
+		case '[':
+			// control sequence ends with a byte in range 0x40 to 0x7E
+			i += 2
+			for i < len(str) && (str[i] < 0x40 || str[i] > 0x7E) {
+				i++
+			}
+		case ']':
+			// operating system command ends with BEL or ESC \
+			i += 2
+			for i < len(str) && str[i] != '\a' && !(str[i] == '\x1b' && i+1 < len(str) && str[i+1] == '\\') {
+				i++
+			}
+			if i < len(str) && str[i] == '\x1b' {
+				i++
+			}
+		default:
+			i++
+		}
+	}
+	return sb.String()
+}
+
+// Graphemes splits str into grapheme clusters, i.e. user-perceived characters, and returns them in order.
+func Graphemes(str string) []string {
+	clusters := make([]string, 0, len(str))
+	state := -1
+	for len(str) > 0 {
+		var cluster string
+		cluster, str, _, state = uniseg.FirstGraphemeClusterInString(str, state)
+		clusters = append(clusters, cluster)
+	}
+	return clusters
+}
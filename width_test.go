@@ -0,0 +1,50 @@
+package console
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringWidth(t *testing.T) {
+	assert.Equal(t, 0, StringWidth(""))
+	assert.Equal(t, 3, StringWidth("foo"))
+	assert.Equal(t, 3, StringWidth("für"))
+	// decomposed umlaut with combining diaeresis
+	assert.Equal(t, 3, StringWidth("fu\u0308r"))
+	assert.Equal(t, 4, StringWidth("日本"))
+	assert.Equal(t, 2, StringWidth("👍"))
+	assert.Equal(t, 2, StringWidth("\U0001F469\u200D\U0001F469\u200D\U0001F467"))
+	assert.Equal(t, 2, StringWidth("🇩🇪"))
+	assert.Equal(t, 3, StringWidth(Style{Foreground: ColorRed}.render(ColorDepth16, "foo")))
+}
+
+func TestStripEscapeSequences(t *testing.T) {
+	assert.Equal(t, "foo", StripEscapeSequences("foo"))
+	assert.Equal(t, "foo bar", StripEscapeSequences("\x1b[1;31mfoo\x1b[0m bar"))
+	assert.Equal(t, "title", StripEscapeSequences("\x1b]0;window\atitle\x1b]8;;http://x\x1b\\"))
+	assert.Equal(t, "ab", StripEscapeSequences("a\x1b7b\x1b"))
+}
+
+func TestGraphemes(t *testing.T) {
+	assert.Equal(t, []string{}, Graphemes(""))
+	assert.Equal(t, []string{"f", "u\u0308", "r"}, Graphemes("fu\u0308r"))
+	assert.Equal(t, []string{"日", "本", "\U0001F469\u200D\U0001F469\u200D\U0001F467", "🇩🇪"}, Graphemes("日本\U0001F469\u200D\U0001F469\u200D\U0001F467🇩🇪"))
+}
+
+func TestPrintListWideCharacters(t *testing.T) {
+	var out bytes.Buffer
+	c := New(nil, &out, &Options{GetSize: func() (int, int, error) { return 20, 10, nil }})
+
+	assert.NoError(t, c.PrintList([]string{"日本語", "ab", "über", "👍"}))
+	assert.Equal(t, "日本語  ab    \nüber    👍    \n", out.String())
+}
+
+func TestPrintListNarrowTerminal(t *testing.T) {
+	var out bytes.Buffer
+	c := New(nil, &out, &Options{GetSize: func() (int, int, error) { return 4, 10, nil }})
+
+	assert.NoError(t, c.PrintList([]string{"日本語", "ab"}))
+	assert.Equal(t, "日本語\nab    \n", out.String())
+}
@@ -0,0 +1,93 @@
+package console
+
+// Windows console input constants, see KEY_EVENT_RECORD.
+const (
+	winRightAltPressed  = 0x1
+	winLeftAltPressed   = 0x2
+	winRightCtrlPressed = 0x4
+	winLeftCtrlPressed  = 0x8
+	winShiftPressed     = 0x10
+)
+
+var (
+	// winVirtualKeys maps virtual key codes of the windows console to keys.
+	winVirtualKeys = map[uint16]Key{
+		0x08: KeyBackspace,
+		0x09: KeyTab,
+		0x0d: KeyEnter,
+		0x1b: KeyEscape,
+		0x20: KeySpace,
+		0x21: KeyPageUp,
+		0x22: KeyPageDown,
+		0x23: KeyEnd,
+		0x24: KeyHome,
+		0x25: KeyLeft,
+		0x26: KeyUp,
+		0x27: KeyRight,
+		0x28: KeyDown,
+		0x2d: KeyInsert,
+		0x2e: KeyDelete,
+		0x70: KeyF1,
+		0x71: KeyF2,
+		0x72: KeyF3,
+		0x73: KeyF4,
+		0x74: KeyF5,
+		0x75: KeyF6,
+		0x76: KeyF7,
+		0x77: KeyF8,
+		0x78: KeyF9,
+		0x79: KeyF10,
+		0x7a: KeyF11,
+		0x7b: KeyF12,
+	}
+)
+
+// winKeyRecord mirrors the KEY_EVENT_RECORD of the windows console.
+type winKeyRecord struct {
+	keyDown         int32
+	repeatCount     uint16
+	virtualKeyCode  uint16
+	virtualScanCode uint16
+	unicodeChar     uint16
+	controlKeyState uint32
+}
+
+// keyEvent returns the key event of a pressed key. Key releases and lone modifier keys are ignored.
+func (r *winKeyRecord) keyEvent() (KeyEvent, bool) {
+	if r.keyDown == 0 {
+		return KeyEvent{}, false
+	}
+
+	var mods Modifiers
+	if r.controlKeyState&(winLeftCtrlPressed|winRightCtrlPressed) != 0 {
+		mods |= ModCtrl
+	}
+	if r.controlKeyState&(winLeftAltPressed|winRightAltPressed) != 0 {
+		mods |= ModAlt
+	}
+	if r.controlKeyState&winShiftPressed != 0 {
+		mods |= ModShift
+	}
+	if mods.Has(ModCtrl|ModAlt) && r.unicodeChar >= ' ' {
+		// AltGr is reported as Ctrl+Alt and only produces the character
+		return KeyEvent{Rune: rune(r.unicodeChar)}, true
+	}
+
+	if key, ok := winVirtualKeys[r.virtualKeyCode]; ok {
+		return KeyEvent{Key: key, Modifiers: mods}, true
+	}
+
+	if r.unicodeChar > 0 && r.unicodeChar < ' ' {
+		// control characters like Ctrl+A
+		event := KeyEvent{Key: Key(r.unicodeChar), Modifiers: mods &^ ModShift}
+		if !isCtrlKey(event.Key) {
+			event.Modifiers &^= ModCtrl
+		}
+		return event, true
+	}
+	if r.unicodeChar != 0 {
+		// shift is already part of the character
+		return KeyEvent{Rune: rune(r.unicodeChar), Modifiers: mods &^ ModShift}, true
+	}
+	return KeyEvent{}, false
+}
@@ -0,0 +1,36 @@
+package console
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWinKeyRecord(t *testing.T) {
+	tests := []struct {
+		name     string
+		record   winKeyRecord
+		expected KeyEvent
+	}{
+		{"Rune", winKeyRecord{keyDown: 1, virtualKeyCode: 'B', unicodeChar: 'B', controlKeyState: winShiftPressed}, KeyEvent{Rune: 'B'}},
+		{"AltB", winKeyRecord{keyDown: 1, virtualKeyCode: 'B', unicodeChar: 'b', controlKeyState: winLeftAltPressed}, KeyEvent{Rune: 'b', Modifiers: ModAlt}},
+		{"AltGr", winKeyRecord{keyDown: 1, virtualKeyCode: 'Q', unicodeChar: '@', controlKeyState: winRightAltPressed | winLeftCtrlPressed}, KeyEvent{Rune: '@'}},
+		{"CtrlA", winKeyRecord{keyDown: 1, virtualKeyCode: 'A', unicodeChar: 1, controlKeyState: winLeftCtrlPressed}, KeyEvent{Key: KeyCtrlA, Modifiers: ModCtrl}},
+		{"CtrlLeft", winKeyRecord{keyDown: 1, virtualKeyCode: 0x25, controlKeyState: winRightCtrlPressed}, KeyEvent{Key: KeyLeft, Modifiers: ModCtrl}},
+		{"ShiftTab", winKeyRecord{keyDown: 1, virtualKeyCode: 0x09, unicodeChar: '\t', controlKeyState: winShiftPressed}, KeyEvent{Key: KeyTab, Modifiers: ModShift}},
+		{"Backspace", winKeyRecord{keyDown: 1, virtualKeyCode: 0x08, unicodeChar: 8}, KeyEvent{Key: KeyBackspace}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			event, ok := test.record.keyEvent()
+			assert.True(t, ok)
+			assert.Equal(t, test.expected, event)
+		})
+	}
+
+	// releases and lone modifier keys are ignored
+	_, ok := (&winKeyRecord{keyDown: 0, virtualKeyCode: 'A', unicodeChar: 'a'}).keyEvent()
+	assert.False(t, ok)
+	_, ok = (&winKeyRecord{keyDown: 1, virtualKeyCode: 0x10, controlKeyState: winShiftPressed}).keyEvent()
+	assert.False(t, ok)
+}